@@ -0,0 +1,443 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// AtomicExtension is the URI of the Atomic Operations extension. It is
+// registered by HandleOperations.
+const AtomicExtension = "https://jsonapi.org/ext/atomic"
+
+// Operation codes of the Atomic Operations extension.
+const (
+	OpAdd    = "add"
+	OpUpdate = "update"
+	OpRemove = "remove"
+)
+
+const operationsEndpoint = "operations"
+
+type (
+	// Transaction is begun for each request to the operations endpoint so the
+	// handlers of its operations succeed or fail together. Commit is called
+	// when every operation succeeds, Rollback otherwise.
+	Transaction interface {
+		Commit() error
+		Rollback() error
+	}
+
+	// BeginFunc begins a Transaction for a request to the operations
+	// endpoint. The returned context is used for the requests passed to the
+	// handlers of each operation so they may retrieve the transaction.
+	BeginFunc func(ctx context.Context) (context.Context, Transaction, error)
+
+	operation struct {
+		Op   string          `json:"op"`
+		Ref  *operationRef   `json:"ref"`
+		Href string          `json:"href"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	operationRef struct {
+		Type         string `json:"type"`
+		ID           string `json:"id"`
+		LID          string `json:"lid"`
+		Relationship string `json:"relationship"`
+	}
+
+	operationResult struct {
+		Data json.RawMessage `json:"data,omitempty"`
+		Meta Meta            `json:"meta,omitempty"`
+	}
+
+	// operationRecorder captures the response of the handler for a single
+	// operation.
+	operationRecorder struct {
+		header http.Header
+		status int
+		body   bytes.Buffer
+	}
+)
+
+// HandleOperations enables the Atomic Operations extension. Requests to
+// POST `/operations` with an `atomic:operations` document are dispatched, in
+// order, to the create, update, delete and relationship handlers registered
+// for the resource types they target, and the results are returned as
+// `atomic:results`. Local IDs (lid) of resources created by earlier
+// operations are replaced with their ids. If begin is not nil, it is called
+// before the first operation and the transaction is committed or rolled back
+// depending on whether every operation succeeded.
+func (mux *ServeMux) HandleOperations(begin BeginFunc) {
+	mux.RegisterExtension(AtomicExtension)
+	mux.atomic = true
+	mux.begin = begin
+}
+
+func (mux ServeMux) handleOperations(res http.ResponseWriter, req *http.Request, negotiated MediaTypeParams) {
+	if !negotiated.HasExt(AtomicExtension) {
+		negotiated.Ext = append(negotiated.Ext, AtomicExtension)
+	}
+	res.Header().Set("Content-Type", negotiated.ContentType())
+
+	var doc TopLevelDocument
+	doc.SetInternalErrorRedaction(mux.RedactInternalErrors)
+	doc.applyMediaTypeParams(negotiated)
+
+	allowed := []string{http.MethodPost, http.MethodOptions}
+	switch req.Method {
+	case http.MethodPost:
+	case http.MethodOptions:
+		writeOptions(res, allowed)
+		return
+	default:
+		mux.writeMethodNotAllowed(res, req, allowed)
+		return
+	}
+
+	_, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if !containsString(strings.Fields(params["ext"]), AtomicExtension) {
+		doc.AppendError(ErrUnsupportedMediaType(fmt.Sprintf("Content-Type must apply the %s extension", AtomicExtension)))
+		mux.writeDocument(res, req, &doc, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	operations, err := decodeOperations(req.Body)
+	if err != nil {
+		doc.AppendError(err)
+		mux.writeDocument(res, req, &doc, http.StatusBadRequest)
+		return
+	}
+
+	ctx := req.Context()
+	var tx Transaction
+	if mux.begin != nil {
+		if internal, panicked := mux.protect(req, func() { ctx, tx, err = mux.begin(ctx) }); panicked {
+			err = internal
+		}
+		if err != nil {
+			doc.AppendError(err)
+			mux.writeDocument(res, req, &doc, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	results, err := mux.runOperations(ctx, req.Header, operations)
+	if err != nil {
+		if tx != nil {
+			mux.protect(req, func() { tx.Rollback() })
+		}
+		doc.AppendError(err)
+		mux.writeDocument(res, req, &doc, http.StatusInternalServerError)
+		return
+	}
+	if tx != nil {
+		if internal, panicked := mux.protect(req, func() { err = tx.Commit() }); panicked {
+			err = internal
+		}
+		if err != nil {
+			doc.AppendError(err)
+			mux.writeDocument(res, req, &doc, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	empty := true
+	for _, result := range results {
+		if result.Data != nil || len(result.Meta) != 0 {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		mux.writeDocument(res, req, &doc, http.StatusNoContent)
+		return
+	}
+
+	buf, err := json.Marshal(struct {
+		Results []operationResult `json:"atomic:results"`
+		topLevelMembers
+	}{results, doc.topLevelMembers})
+	if err != nil {
+		doc.AppendError(Error{Detail: "response could not be rendered", Status: http.StatusInternalServerError})
+		mux.writeDocument(res, req, &doc, http.StatusInternalServerError)
+		return
+	}
+	res.WriteHeader(http.StatusOK)
+	res.Write(buf)
+}
+
+// decodeOperations reads the `atomic:operations` member of a request
+// document. Operations are kept as generic values so local IDs can be
+// resolved before each one is run.
+func decodeOperations(body io.Reader) ([]interface{}, error) {
+	if body == nil {
+		return nil, decodingError("", "request body is missing")
+	}
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, decodingError("", "request body must be a JSON object")
+	}
+	operations, ok := doc["atomic:operations"].([]interface{})
+	if !ok || len(operations) == 0 {
+		return nil, decodingError("/atomic:operations", "atomic:operations must be an array of operation objects")
+	}
+	return operations, nil
+}
+
+// runOperations runs each operation in order and stops at the first one
+// that fails. The errors of the failed operation are returned with source
+// pointers relative to the request document. header holds the headers of the
+// operations request, such as Authorization, passed on to each operation.
+func (mux ServeMux) runOperations(ctx context.Context, header http.Header, operations []interface{}) ([]operationResult, error) {
+	lids := make(map[string]string)
+	results := make([]operationResult, 0, len(operations))
+	for i, raw := range operations {
+		pointer := "/atomic:operations/" + strconv.Itoa(i)
+
+		op, err := resolveOperation(raw, pointer, lids)
+		if err != nil {
+			return nil, err
+		}
+		req, err := op.request(ctx, header, pointer)
+		if err != nil {
+			return nil, err
+		}
+
+		rec := &operationRecorder{header: make(http.Header)}
+		mux.ServeHTTP(rec, req)
+
+		result, err := rec.result(pointer)
+		if err != nil {
+			return nil, err
+		}
+		if id, lid := op.identity(); op.Op == OpAdd && lid != "" {
+			var created struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(result.Data, &created)
+			if created.ID != "" {
+				id = created.ID
+			}
+			if id == "" {
+				return nil, decodingError(pointer+"/data/lid", fmt.Sprintf("local id %q can not be assigned as the created resource has no id", lid))
+			}
+			lids[lid] = id
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// resolveOperation replaces local IDs of resources created by earlier
+// operations with their ids and decodes the operation. The lid of the
+// primary data of an add operation is kept as it identifies the resource
+// being created.
+func resolveOperation(raw interface{}, pointer string, lids map[string]string) (operation, error) {
+	var op operation
+	members, ok := raw.(map[string]interface{})
+	if !ok {
+		return op, decodingError(pointer, "an operation must be an object")
+	}
+	for name, value := range members {
+		if name != "ref" && name != "data" {
+			continue
+		}
+		top := name == "data" && members["op"] == OpAdd
+		if err := resolveLIDs(value, pointer+"/"+name, lids, top); err != nil {
+			return op, err
+		}
+	}
+
+	buf, err := json.Marshal(members)
+	if err != nil {
+		return op, decodingError(pointer, err.Error())
+	}
+	if err := json.Unmarshal(buf, &op); err != nil {
+		return op, decodingError(pointer, err.Error())
+	}
+	return op, nil
+}
+
+// resolveLIDs walks value and sets the id of every object with a known lid.
+// When skip is true the lid of value itself is left alone.
+func resolveLIDs(value interface{}, pointer string, lids map[string]string, skip bool) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if lid, ok := v["lid"].(string); ok && !skip {
+			id, found := lids[lid]
+			if !found {
+				return decodingError(pointer+"/lid", fmt.Sprintf("local id %q has not been assigned by a previous operation", lid))
+			}
+			v["id"] = id
+			delete(v, "lid")
+		}
+		for name, member := range v {
+			if err := resolveLIDs(member, pointer+"/"+name, lids, false); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, member := range v {
+			if err := resolveLIDs(member, pointer+"/"+strconv.Itoa(i), lids, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// identity returns the id and local ID of the primary data of an add
+// operation. The id is only set when it is generated by the client.
+func (op operation) identity() (id, lid string) {
+	var data struct {
+		ID  string `json:"id"`
+		LID string `json:"lid"`
+	}
+	json.Unmarshal(op.Data, &data)
+	return data.ID, data.LID
+}
+
+// request builds the request to dispatch the operation to the handler
+// registered for its target. It has the headers in header except those
+// describing the body of the operations request.
+func (op operation) request(ctx context.Context, header http.Header, pointer string) (*http.Request, error) {
+	var target struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	if firstByte(op.Data) == '{' {
+		json.Unmarshal(op.Data, &target)
+	}
+	var relationship string
+	if op.Ref != nil {
+		target.Type, relationship = op.Ref.Type, op.Ref.Relationship
+		if op.Ref.ID != "" {
+			target.ID = op.Ref.ID
+		}
+	}
+
+	var p string
+	switch {
+	case op.Href != "":
+		u, err := url.Parse(op.Href)
+		if err != nil {
+			return nil, decodingError(pointer+"/href", "href must be a URI")
+		}
+		p = u.Path
+	case target.Type == "":
+		return nil, decodingError(pointer, "an operation must target a resource type")
+	case relationship != "":
+		p = path.Join("/", target.Type, target.ID, "relationships", relationship)
+	case op.Op == OpAdd:
+		p = path.Join("/", target.Type)
+	default:
+		p = path.Join("/", target.Type, target.ID)
+	}
+	if endpoint, _ := shiftPath(p); endpoint == operationsEndpoint {
+		return nil, decodingError(pointer, "an operation must not target the operations endpoint")
+	}
+
+	var method string
+	switch op.Op {
+	case OpAdd:
+		method = http.MethodPost
+	case OpUpdate:
+		method = http.MethodPatch
+	case OpRemove:
+		method = http.MethodDelete
+	default:
+		return nil, decodingError(pointer+"/op", `op must be one of "add", "update" or "remove"`)
+	}
+
+	var body io.Reader
+	if op.Data != nil {
+		buf, err := json.Marshal(struct {
+			Data json.RawMessage `json:"data"`
+		}{op.Data})
+		if err != nil {
+			return nil, decodingError(pointer+"/data", err.Error())
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p, body)
+	if err != nil {
+		return nil, decodingError(pointer, err.Error())
+	}
+	for key, values := range header {
+		if key == "Content-Type" || key == "Content-Length" {
+			continue
+		}
+		req.Header[key] = append([]string(nil), values...)
+	}
+	req.Header.Set("Accept", ContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", ContentType)
+	}
+	return req, nil
+}
+
+// result returns the result of an operation or its errors, with source
+// pointers prefixed with the pointer to the operation.
+func (rec *operationRecorder) result(pointer string) (operationResult, error) {
+	var doc struct {
+		Errors []Error         `json:"errors"`
+		Data   json.RawMessage `json:"data"`
+		Meta   Meta            `json:"meta"`
+	}
+	if rec.body.Len() != 0 {
+		if err := json.Unmarshal(rec.body.Bytes(), &doc); err != nil {
+			return operationResult{}, err
+		}
+	}
+
+	if rec.status >= http.StatusBadRequest || len(doc.Errors) != 0 {
+		if len(doc.Errors) == 0 {
+			doc.Errors = []Error{{Status: rec.status, Detail: http.StatusText(rec.status)}}
+		}
+		errs := make([]error, len(doc.Errors))
+		for i, e := range doc.Errors {
+			switch {
+			case e.Source == nil || (e.Source.Pointer == "" && e.Source.Parameter == "" && e.Source.Header == ""):
+				e.Source = PointerSource(pointer)
+			case e.Source.Pointer != "":
+				e.Source = PointerSource(pointer + e.Source.Pointer)
+			}
+			errs[i] = e
+		}
+		return operationResult{}, errors.Join(errs...)
+	}
+
+	if string(doc.Data) == "null" {
+		doc.Data = nil
+	}
+	return operationResult{Data: doc.Data, Meta: doc.Meta}, nil
+}
+
+func (rec *operationRecorder) Header() http.Header { return rec.header }
+
+func (rec *operationRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *operationRecorder) Write(buf []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(buf)
+}
@@ -0,0 +1,285 @@
+package jsonapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+type fakeTransaction struct {
+	committed, rolledBack bool
+}
+
+func (tx *fakeTransaction) Commit() error   { tx.committed = true; return nil }
+func (tx *fakeTransaction) Rollback() error { tx.rolledBack = true; return nil }
+
+type transactionContextKey struct{}
+
+func TestHandle_ServeHTTP_Operations(t *testing.T) {
+	const atomicContentType = `application/vnd.api+json; ext="https://jsonapi.org/ext/atomic"`
+
+	newMux := func(tx *fakeTransaction) *jsonapi.ServeMux {
+		var (
+			mux     jsonapi.ServeMux
+			created int
+		)
+		mux.HandleOperations(func(ctx context.Context) (context.Context, jsonapi.Transaction, error) {
+			return context.WithValue(ctx, transactionContextKey{}, tx), tx, nil
+		})
+		for _, endpoint := range []string{"authors", "articles"} {
+			endpoint := endpoint
+			mux.HandleCreate(endpoint, jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+				if req.Context().Value(transactionContextKey{}) != tx {
+					res.AppendError(errors.New("missing transaction"))
+					return
+				}
+				body, err := jsonapi.DecodeCreateRequest(req)
+				if err != nil {
+					res.AppendError(err)
+					return
+				}
+				created++
+				res.SetData(endpoint, strconv.Itoa(created), body.Data.Attributes, body.Data.Relationships, nil, nil)
+			}))
+		}
+		mux.PermitClientGeneratedIDs("people", nil)
+		for _, endpoint := range []string{"people", "tags"} {
+			mux.HandleCreate(endpoint, jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+				res.SetStatus(http.StatusNoContent)
+			}))
+		}
+		mux.HandleUpdateRelationships("articles", "author", jsonapi.UpdateRelationshipsFunc(func(res jsonapi.UpdateRelationshipsResponder, req *http.Request, id, relation string) {}))
+		mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {
+			if id != "1" {
+				res.AppendError(jsonapi.ErrNotFound("articles", id))
+			}
+		}))
+		return &mux
+	}
+
+	for _, tt := range []struct {
+		name, contentType, body string
+
+		status                int
+		response              string
+		committed, rolledBack bool
+	}{
+		{
+			"adding resources referenced by local ids", atomicContentType,
+			`{"atomic:operations": [
+				{"op": "add", "data": {"type": "authors", "lid": "a", "attributes": {"name": "dgeb"}}},
+				{"op": "add", "data": {"type": "articles", "attributes": {"title": "JSON API paints my bikeshed!"}, "relationships": {"author": {"data": {"type": "authors", "lid": "a"}}}}},
+				{"op": "update", "ref": {"type": "articles", "id": "2", "relationship": "author"}, "data": {"type": "authors", "lid": "a"}}
+			]}`,
+			http.StatusOK,
+			`{"atomic:results":[{"data":{"id":"1","type":"authors","attributes":{"name":"dgeb"}}},{"data":{"id":"2","type":"articles","attributes":{"title":"JSON API paints my bikeshed!"},"relationships":{"author":{"data":{"id":"1","type":"authors"}}}}},{}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			true, false,
+		},
+		{
+			"adding a resource with a client generated id referenced by a local id", atomicContentType,
+			`{"atomic:operations": [
+				{"op": "add", "data": {"type": "people", "id": "p1", "lid": "p"}},
+				{"op": "add", "data": {"type": "articles", "attributes": {"title": "Ids"}, "relationships": {"author": {"data": {"type": "people", "lid": "p"}}}}}
+			]}`,
+			http.StatusOK,
+			`{"atomic:results":[{},{"data":{"id":"1","type":"articles","attributes":{"title":"Ids"},"relationships":{"author":{"data":{"id":"p1","type":"people"}}}}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			true, false,
+		},
+		{
+			"an added resource referenced by a local id has no id", atomicContentType,
+			`{"atomic:operations": [{"op": "add", "data": {"type": "tags", "lid": "t"}}]}`,
+			http.StatusBadRequest,
+			`{"errors":[{"status":"400","detail":"local id \"t\" can not be assigned as the created resource has no id","source":{"pointer":"/atomic:operations/0/data/lid"}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			false, true,
+		},
+		{
+			"every operation has an empty result", atomicContentType,
+			`{"atomic:operations": [{"op": "remove", "ref": {"type": "articles", "id": "1"}}]}`,
+			http.StatusNoContent, "",
+			true, false,
+		},
+		{
+			"an operation fails", atomicContentType,
+			`{"atomic:operations": [
+				{"op": "add", "data": {"type": "authors", "attributes": {"name": "dgeb"}}},
+				{"op": "remove", "ref": {"type": "articles", "id": "13"}}
+			]}`,
+			http.StatusNotFound,
+			`{"errors":[{"status":"404","code":"not-found","title":"Not Found","detail":"articles \"13\" not found","source":{"pointer":"/atomic:operations/1"}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			false, true,
+		},
+		{
+			"an operation has an invalid document", atomicContentType,
+			`{"atomic:operations": [{"op": "add", "data": {"type": "articles", "attributes": []}}]}`,
+			http.StatusBadRequest,
+			`{"errors":[{"status":"400","detail":"attributes must be an object","source":{"pointer":"/atomic:operations/0/data/attributes"}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			false, true,
+		},
+		{
+			"a local id is not assigned", atomicContentType,
+			`{"atomic:operations": [{"op": "update", "ref": {"type": "articles", "lid": "b", "relationship": "author"}, "data": null}]}`,
+			http.StatusBadRequest,
+			`{"errors":[{"status":"400","detail":"local id \"b\" has not been assigned by a previous operation","source":{"pointer":"/atomic:operations/0/ref/lid"}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			false, true,
+		},
+		{
+			"the operation code is not known", atomicContentType,
+			`{"atomic:operations": [{"op": "replace", "ref": {"type": "articles", "id": "1"}}]}`,
+			http.StatusBadRequest,
+			`{"errors":[{"status":"400","detail":"op must be one of \"add\", \"update\" or \"remove\"","source":{"pointer":"/atomic:operations/0/op"}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			false, true,
+		},
+		{
+			"the extension is not applied", jsonapi.ContentType,
+			`{"atomic:operations": [{"op": "remove", "ref": {"type": "articles", "id": "1"}}]}`,
+			http.StatusUnsupportedMediaType,
+			`{"errors":[{"status":"415","code":"unsupported-media-type","title":"Unsupported Media Type","detail":"Content-Type must apply the https://jsonapi.org/ext/atomic extension","source":{"header":"Content-Type"}}],"jsonapi":{"ext":["https://jsonapi.org/ext/atomic"]}}`,
+			false, false,
+		},
+	} {
+		t.Run("When "+tt.name, func(t *testing.T) {
+			var tx fakeTransaction
+			mux := newMux(&tx)
+
+			req, err := http.NewRequest(http.MethodPost, "/operations", strings.NewReader(tt.body))
+			mustNotErr(t, err)
+			req.Header.Set("Accept", atomicContentType)
+			req.Header.Set("Content-Type", tt.contentType)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("it should respond with status %d", tt.status)
+				t.Log(res.Code)
+			}
+			if body := res.Body.String(); body != tt.response {
+				t.Error("it should respond with the expected document")
+				t.Log(body)
+			}
+			if tt.status != http.StatusNoContent && res.Header().Get("Content-Type") != atomicContentType {
+				t.Error("it should respond with the atomic extension applied")
+				t.Log(res.Header().Get("Content-Type"))
+			}
+			if tx.committed != tt.committed {
+				t.Errorf("it should commit the transaction only when every operation succeeds")
+			}
+			if tx.rolledBack != tt.rolledBack {
+				t.Errorf("it should roll back the transaction when an operation fails")
+			}
+		})
+	}
+
+	t.Run("When middleware checks the headers of an operation", func(t *testing.T) {
+		tx := &fakeTransaction{}
+		mux := newMux(tx)
+		mux.Use(func(next jsonapi.Handler) jsonapi.Handler {
+			return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+				if req.Header.Get("Authorization") != "Bearer token" {
+					res.AppendError(jsonapi.Error{Status: http.StatusUnauthorized})
+					return
+				}
+				if req.Header.Get("Content-Type") != jsonapi.ContentType {
+					res.AppendError(jsonapi.Error{Status: http.StatusUnsupportedMediaType})
+					return
+				}
+				next(res, req, route)
+			}
+		})
+
+		req, err := http.NewRequest(http.MethodPost, "/operations", strings.NewReader(`{"atomic:operations": [{"op": "add", "data": {"type": "authors", "attributes": {"name": "dgeb"}}}]}`))
+		mustNotErr(t, err)
+		req.Header.Set("Content-Type", atomicContentType)
+		req.Header.Set("Authorization", "Bearer token")
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusOK {
+			t.Error("it should pass the headers of the operations request to each operation")
+			t.Log(res.Code, res.Body.String())
+		}
+		if !tx.committed {
+			t.Error("it should commit the transaction")
+		}
+	})
+
+	t.Run("When the operations extension is not enabled", func(t *testing.T) {
+		var mux jsonapi.ServeMux
+
+		req, err := http.NewRequest(http.MethodPost, "/operations", strings.NewReader(`{"atomic:operations": []}`))
+		mustNotErr(t, err)
+		req.Header.Set("Content-Type", atomicContentType)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusUnsupportedMediaType {
+			t.Error("it should not support the extension")
+			t.Log(res.Code)
+		}
+	})
+}
+
+type panickingTransaction struct{}
+
+func (panickingTransaction) Commit() error   { panic("commit failed") }
+func (panickingTransaction) Rollback() error { panic("rollback failed") }
+
+func TestHandle_ServeHTTP_Operations_Panics(t *testing.T) {
+	const atomicContentType = `application/vnd.api+json; ext="https://jsonapi.org/ext/atomic"`
+
+	for _, tt := range []struct {
+		name  string
+		begin jsonapi.BeginFunc
+	}{
+		{"beginning the transaction panics", func(ctx context.Context) (context.Context, jsonapi.Transaction, error) {
+			panic("begin failed")
+		}},
+		{"committing the transaction panics", func(ctx context.Context) (context.Context, jsonapi.Transaction, error) {
+			return ctx, panickingTransaction{}, nil
+		}},
+	} {
+		t.Run("When "+tt.name, func(t *testing.T) {
+			var (
+				mux      jsonapi.ServeMux
+				reported interface{}
+			)
+			mux.PanicReporter = func(req *http.Request, errorID string, value interface{}, stack []byte) {
+				reported = value
+			}
+			mux.HandleOperations(tt.begin)
+			mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {}))
+
+			req, err := http.NewRequest(http.MethodPost, "/operations", strings.NewReader(`{"atomic:operations": [{"op": "remove", "ref": {"type": "articles", "id": "1"}}]}`))
+			mustNotErr(t, err)
+			req.Header.Set("Accept", atomicContentType)
+			req.Header.Set("Content-Type", atomicContentType)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != http.StatusInternalServerError {
+				t.Error("it should respond with internal server error")
+				t.Log(res.Code)
+			}
+			if !strings.Contains(res.Body.String(), jsonapi.CodeInternal) {
+				t.Error("it should respond with an internal error")
+				t.Log(res.Body.String())
+			}
+			if reported == nil {
+				t.Error("it should report the panic")
+			}
+		})
+	}
+}
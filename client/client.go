@@ -0,0 +1,237 @@
+// Package client implements a client for JSON:API servers such as those
+// built with jsonapi.ServeMux.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/crhntr/jsonapi"
+)
+
+// Client sends requests to a JSON:API server. It's zero value is not valid;
+// BaseURL must be set.
+type Client struct {
+	// BaseURL is the root of the api, for example "https://example.com/api".
+	BaseURL string
+
+	// HTTPClient is used to send requests. If it is nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+}
+
+// FetchOne requests GET `/:endpoint/:id`.
+func (client Client) FetchOne(ctx context.Context, endpoint, id string, query url.Values) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodGet, client.url(query, endpoint, id), nil)
+}
+
+// FetchCollection requests GET `/:endpoint`. Use NextPage to fetch
+// subsequent pages.
+func (client Client) FetchCollection(ctx context.Context, endpoint string, query url.Values) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodGet, client.url(query, endpoint), nil)
+}
+
+// FetchAll requests GET `/:endpoint` and follows `next` pagination links
+// until there are none left, returning the primary data of every page.
+func (client Client) FetchAll(ctx context.Context, endpoint string, query url.Values) (jsonapi.Resources, error) {
+	doc, err := client.FetchCollection(ctx, endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	var resources jsonapi.Resources
+	for {
+		page, _ := doc.Data.(jsonapi.Resources)
+		resources = append(resources, page...)
+
+		if _, hasNext := nextLink(doc); !hasNext {
+			return resources, nil
+		}
+		doc, err = client.NextPage(ctx, doc)
+		if err != nil {
+			return resources, err
+		}
+	}
+}
+
+// NextPage requests the page referenced by the `next` link of a
+// previously fetched document. ErrNoNextPage is returned when doc does not
+// have one.
+func (client Client) NextPage(ctx context.Context, doc jsonapi.TopLevelDocument) (jsonapi.TopLevelDocument, error) {
+	next, ok := nextLink(doc)
+	if !ok {
+		return jsonapi.TopLevelDocument{}, ErrNoNextPage
+	}
+	base, err := url.Parse(client.BaseURL)
+	if err != nil {
+		return jsonapi.TopLevelDocument{}, err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return jsonapi.TopLevelDocument{}, err
+	}
+	return client.do(ctx, http.MethodGet, base.ResolveReference(ref).String(), nil)
+}
+
+// ErrNoNextPage is returned by NextPage when a document has no `next` link.
+var ErrNoNextPage = errors.New("document does not have a next link")
+
+// FetchRelated requests GET `/:endpoint/:id/:relation`.
+func (client Client) FetchRelated(ctx context.Context, endpoint, id, relation string, query url.Values) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodGet, client.url(query, endpoint, id, relation), nil)
+}
+
+// FetchRelationships requests GET `/:endpoint/:id/relationships/:relation`.
+func (client Client) FetchRelationships(ctx context.Context, endpoint, id, relation string) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodGet, client.url(nil, endpoint, id, "relationships", relation), nil)
+}
+
+// Create requests POST `/:endpoint` with resource as the primary data. The
+// resource ID is only sent when it is set.
+func (client Client) Create(ctx context.Context, endpoint string, resource jsonapi.Resource) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodPost, client.url(nil, endpoint), requestResource(resource))
+}
+
+// Update requests PATCH `/:endpoint/:id` with resource as the primary data.
+func (client Client) Update(ctx context.Context, endpoint string, resource jsonapi.Resource) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodPatch, client.url(nil, endpoint, resource.ID), requestResource(resource))
+}
+
+// Delete requests DELETE `/:endpoint/:id`. The document is empty unless the
+// server responds with 200 OK and, for example, meta.
+func (client Client) Delete(ctx context.Context, endpoint, id string) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodDelete, client.url(nil, endpoint, id), nil)
+}
+
+// UpdateRelationship requests PATCH `/:endpoint/:id/relationships/:relation`
+// replacing the relationship with linkage.
+func (client Client) UpdateRelationship(ctx context.Context, endpoint, id, relation string, linkage jsonapi.ResourceLinkage) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodPatch, client.url(nil, endpoint, id, "relationships", relation), linkage)
+}
+
+// AddToMany requests POST `/:endpoint/:id/relationships/:relation` adding
+// identities to a to-many relationship.
+func (client Client) AddToMany(ctx context.Context, endpoint, id, relation string, identities []jsonapi.Identity) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodPost, client.url(nil, endpoint, id, "relationships", relation), toManyData(identities))
+}
+
+// RemoveToMany requests DELETE `/:endpoint/:id/relationships/:relation`
+// removing identities from a to-many relationship.
+func (client Client) RemoveToMany(ctx context.Context, endpoint, id, relation string, identities []jsonapi.Identity) (jsonapi.TopLevelDocument, error) {
+	return client.do(ctx, http.MethodDelete, client.url(nil, endpoint, id, "relationships", relation), toManyData(identities))
+}
+
+// do sends a request with data, if not nil, as the primary data of the
+// request document. Responses with an errors member are converted to a
+// jsonapi.Error or, when there are several, errors joined with errors.Join.
+func (client Client) do(ctx context.Context, method, u string, data interface{}) (jsonapi.TopLevelDocument, error) {
+	var body io.Reader
+	if data != nil {
+		buf, err := json.Marshal(struct {
+			Data interface{} `json:"data"`
+		}{data})
+		if err != nil {
+			return jsonapi.TopLevelDocument{}, err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := jsonapi.NewRequest(method, u, body)
+	if err != nil {
+		return jsonapi.TopLevelDocument{}, err
+	}
+	req = req.WithContext(ctx)
+
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return jsonapi.TopLevelDocument{}, err
+	}
+	defer res.Body.Close()
+
+	buf, err := io.ReadAll(res.Body)
+	if err != nil {
+		return jsonapi.TopLevelDocument{}, err
+	}
+
+	success := res.StatusCode >= 200 && res.StatusCode < 300
+	if res.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(buf)) == 0 {
+		if !success {
+			return jsonapi.TopLevelDocument{}, jsonapi.Error{Status: res.StatusCode, Detail: http.StatusText(res.StatusCode)}
+		}
+		return jsonapi.TopLevelDocument{}, nil
+	}
+
+	var doc jsonapi.TopLevelDocument
+	decodeErr := doc.UnmarshalJSON(buf)
+	if len(doc.Errors) != 0 {
+		errs := make([]error, 0, len(doc.Errors))
+		for _, e := range doc.Errors {
+			if e.Status == 0 {
+				e.Status = res.StatusCode
+			}
+			errs = append(errs, e)
+		}
+		if len(errs) == 1 {
+			return doc, errs[0]
+		}
+		return doc, errors.Join(errs...)
+	}
+	if !success {
+		return doc, jsonapi.Error{Status: res.StatusCode, Detail: http.StatusText(res.StatusCode)}
+	}
+	return doc, decodeErr
+}
+
+func (client Client) url(query url.Values, segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	u := strings.TrimSuffix(client.BaseURL, "/") + path.Clean("/"+strings.Join(escaped, "/"))
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func nextLink(doc jsonapi.TopLevelDocument) (string, bool) {
+	link, ok := doc.Links["next"]
+	if !ok || link.Empty() {
+		return "", false
+	}
+	if link.Object.HREF != "" {
+		return link.Object.HREF, true
+	}
+	return link.String, true
+}
+
+// requestResource omits the id member of a resource when it is not set as
+// a server may forbid client generated IDs.
+func requestResource(resource jsonapi.Resource) interface{} {
+	return struct {
+		ID            string                `json:"id,omitempty"`
+		Type          string                `json:"type"`
+		Attributes    interface{}           `json:"attributes,omitempty"`
+		Relationships jsonapi.Relationships `json:"relationships,omitempty"`
+		Meta          jsonapi.Meta          `json:"meta,omitempty"`
+	}{resource.ID, resource.Type, resource.Attributes, resource.Relationships, resource.Meta}
+}
+
+// toManyData ensures an empty list of identities is encoded as an array.
+func toManyData(identities []jsonapi.Identity) []jsonapi.Identity {
+	if identities == nil {
+		return []jsonapi.Identity{}
+	}
+	return identities
+}
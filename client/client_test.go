@@ -0,0 +1,266 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+	"github.com/crhntr/jsonapi/client"
+)
+
+type Article struct {
+	Title string `json:"title"`
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *map[string]Article) {
+	t.Helper()
+
+	articles := map[string]Article{"1": {"One"}, "2": {"Two"}, "3": {"Three"}}
+
+	var mux jsonapi.ServeMux
+
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		article, ok := articles[id]
+		if !ok {
+			res.AppendError(jsonapi.Error{Status: http.StatusNotFound, Title: "Not Found"})
+			return
+		}
+		res.SetData("articles", id, article, nil, nil, nil)
+	}))
+
+	mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page[number]"))
+		if page == 0 {
+			page = 1
+		}
+		id := strconv.Itoa(page)
+		res.AppendData("articles", id, articles[id], nil, nil, nil)
+		if page < len(articles) {
+			res.SetLinks(jsonapi.Links{"next": jsonapi.Link{String: "/articles?page[number]=" + strconv.Itoa(page+1)}})
+		}
+	}))
+
+	mux.HandleCreate("articles", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+		doc, err := jsonapi.DecodeDocument(req.Body)
+		if err != nil {
+			res.AppendError(err)
+			return
+		}
+		resource := doc.Data.(*jsonapi.Resource)
+		if resource.ID != "" {
+			res.AppendError(jsonapi.Error{Status: http.StatusForbidden, Title: "Client generated IDs are not supported"})
+			return
+		}
+		var article Article
+		if err := resource.UnmarshalAttributes(&article); err != nil {
+			res.AppendError(jsonapi.Error{Status: http.StatusBadRequest, Detail: err.Error()})
+			return
+		}
+		id := strconv.Itoa(len(articles) + 1)
+		articles[id] = article
+		res.SetData("articles", id, article, nil, nil, nil)
+	}))
+
+	mux.HandleUpdate("articles", jsonapi.UpdateFunc(func(res jsonapi.UpdateResponder, req *http.Request, id string) {
+		res.AppendError(jsonapi.Error{Status: http.StatusUnprocessableEntity, Title: "Invalid Attribute", Source: jsonapi.AttributeSource("title")})
+		res.AppendError(jsonapi.Error{Status: http.StatusUnprocessableEntity, Title: "Invalid Attribute", Source: jsonapi.AttributeSource("body")})
+	}))
+
+	mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {
+		delete(articles, id)
+		res.SetMeta(jsonapi.Meta{"remaining": len(articles)})
+	}))
+
+	mux.HandleFetchRelated("articles", "author", jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {
+		res.SetData("people", "9", map[string]string{"name": "Dan"}, nil, nil, nil)
+	}))
+
+	mux.HandleFetchRelationships("articles", "tags", jsonapi.FetchRelationshipsFunc(func(res jsonapi.FetchRelationshipsResponder, req *http.Request, id, relation string) {
+		res.AppendIdentity("tags", "2")
+	}))
+
+	mux.HandleAddToMany("articles", "tags", jsonapi.AddToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+		for _, identity := range identities {
+			res.AppendIdentity(identity.Type, identity.ID)
+		}
+	}))
+
+	mux.HandleRemoveToMany("articles", "tags", jsonapi.RemoveToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+		if len(identities) == 0 {
+			return
+		}
+		res.SetDataCollection()
+		res.AppendIdentity("tags", "2")
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &articles
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("when fetching one resource", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		doc, err := c.FetchOne(ctx, "articles", "2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resource, ok := doc.Data.(*jsonapi.Resource)
+		if !ok {
+			t.Fatalf("it should decode a single resource not %T", doc.Data)
+		}
+		var article Article
+		if err := resource.UnmarshalAttributes(&article); err != nil || article.Title != "Two" {
+			t.Error("it should decode the resource attributes")
+			t.Log(err, article)
+		}
+	})
+
+	t.Run("when the server responds with an error", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		_, err := c.FetchOne(ctx, "articles", "404", nil)
+		var apiErr jsonapi.Error
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("it should return a jsonapi.Error not %T", err)
+		}
+		if apiErr.Status != http.StatusNotFound || apiErr.Title != "Not Found" {
+			t.Error("it should return the error from the document")
+			t.Log(apiErr)
+		}
+	})
+
+	t.Run("when the server responds with several errors", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		_, err := c.Update(ctx, "articles", jsonapi.Resource{Type: "articles", ID: "1", Attributes: Article{}})
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("it should join the errors not %T", err)
+		}
+		if errs := joined.Unwrap(); len(errs) != 2 {
+			t.Error("it should return each error")
+			t.Log(errs)
+		}
+	})
+
+	t.Run("when fetching every page of a collection", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		resources, err := c.FetchAll(ctx, "articles", url.Values{"page[number]": {"1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resources) != 3 || resources[0].ID != "1" || resources[2].ID != "3" {
+			t.Error("it should follow the next links")
+			t.Log(resources)
+		}
+	})
+
+	t.Run("when there is no next page", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		doc, err := c.FetchCollection(ctx, "articles", url.Values{"page[number]": {"3"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.NextPage(ctx, doc); err != client.ErrNoNextPage {
+			t.Error("it should return ErrNoNextPage")
+			t.Log(err)
+		}
+	})
+
+	t.Run("when creating and deleting a resource", func(t *testing.T) {
+		server, articles := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		doc, err := c.Create(ctx, "articles", jsonapi.Resource{Type: "articles", Attributes: Article{"Four"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resource := doc.Data.(*jsonapi.Resource)
+		if resource.ID != "4" || (*articles)["4"].Title != "Four" {
+			t.Error("it should create the resource")
+			t.Log(resource)
+		}
+
+		doc, err = c.Delete(ctx, "articles", "4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, found := (*articles)["4"]; found {
+			t.Error("it should delete the resource")
+		}
+		if remaining, _ := doc.Meta["remaining"].(float64); remaining != 3 {
+			t.Error("it should decode the meta of the response")
+			t.Log(doc.Meta)
+		}
+	})
+
+	t.Run("when fetching related resources and relationships", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		doc, err := c.FetchRelated(ctx, "articles", "1", "author", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resource := doc.Data.(*jsonapi.Resource); resource.Type != "people" {
+			t.Error("it should fetch the related resource")
+			t.Log(resource)
+		}
+
+		doc, err = c.FetchRelationships(ctx, "articles", "1", "tags")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resources := doc.Data.(jsonapi.Resources); len(resources) != 1 || resources[0].ID != "2" {
+			t.Error("it should fetch the relationship linkage")
+			t.Log(resources)
+		}
+	})
+
+	t.Run("when mutating a to-many relationship", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		c := client.Client{BaseURL: server.URL}
+
+		doc, err := c.AddToMany(ctx, "articles", "1", "tags", []jsonapi.Identity{{ID: "5", Type: "tags"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resources := doc.Data.(jsonapi.Resources); len(resources) != 1 || resources[0].ID != "5" {
+			t.Error("it should decode the returned linkage")
+			t.Log(resources)
+		}
+
+		doc, err = c.RemoveToMany(ctx, "articles", "1", "tags", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Data != nil {
+			t.Error("it should return an empty document for no content")
+		}
+
+		doc, err = c.RemoveToMany(ctx, "articles", "1", "tags", []jsonapi.Identity{{ID: "5", Type: "tags"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resources, _ := doc.Data.(jsonapi.Resources); len(resources) != 1 || resources[0].ID != "2" {
+			t.Error("it should decode the returned linkage")
+			t.Log(doc.Data)
+		}
+	})
+}
@@ -7,7 +7,11 @@ import (
 
 type endpointContextKeyT int
 
-const endpointContextKey = endpointContextKeyT(0)
+const (
+	endpointContextKey = endpointContextKeyT(iota)
+	fetchParamsContextKey
+	mediaTypeParamsContextKey
+)
 
 func contextWithEndpointValue(req *http.Request, endpoint string) *http.Request {
 	return req.WithContext(context.WithValue(req.Context(), endpointContextKey, endpoint))
@@ -19,3 +23,26 @@ func Endpoint(ctx context.Context) string {
 	endpoint, _ := ctx.Value(endpointContextKey).(string)
 	return endpoint
 }
+
+func contextWithFetchParamsValue(req *http.Request, params FetchParams) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), fetchParamsContextKey, params))
+}
+
+// Params retrieves the query parameters parsed by the router. If they were
+// not set, a zero FetchParams is returned.
+func Params(ctx context.Context) FetchParams {
+	params, _ := ctx.Value(fetchParamsContextKey).(FetchParams)
+	return params
+}
+
+func contextWithMediaTypeParamsValue(req *http.Request, params MediaTypeParams) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), mediaTypeParamsContextKey, params))
+}
+
+// Negotiated retrieves the extensions and profiles the router applies to the
+// response. Handlers may use it to branch on applied profiles. If they were
+// not set, a zero MediaTypeParams is returned.
+func Negotiated(ctx context.Context) MediaTypeParams {
+	params, _ := ctx.Value(mediaTypeParamsContextKey).(MediaTypeParams)
+	return params
+}
@@ -1,7 +1,13 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
 )
 
 type (
@@ -20,15 +26,29 @@ type Resource struct {
 
 	Attributes    interface{}   `json:"attributes,omitempty"`
 	Relationships Relationships `json:"relationships,omitempty"`
+
+	Links Links `json:"links,omitempty"`
+	Meta  Meta  `json:"meta,omitempty"`
 }
 
 // Resources represents an array of “Resource objects” that appear in a JSON:API
 // document to represent a collection of resources.
 type Resources []Resource
 
+// JSONAPIObject represents the top level `jsonapi` member describing the
+// server's implementation.
+type JSONAPIObject struct {
+	Version string   `json:"version,omitempty"`
+	Ext     []string `json:"ext,omitempty"`
+	Profile []string `json:"profile,omitempty"`
+	Meta    Meta     `json:"meta,omitempty"`
+}
+
 type topLevelMembers struct {
-	Meta     Meta      `json:"meta,omitempty"`
-	Included Resources `json:"included,omitempty"`
+	JSONAPI  *JSONAPIObject `json:"jsonapi,omitempty"`
+	Links    Links          `json:"links,omitempty"`
+	Meta     Meta           `json:"meta,omitempty"`
+	Included Resources      `json:"included,omitempty"`
 }
 
 // TopLevelDocument represents the standard root response for all requests.
@@ -37,18 +57,38 @@ type TopLevelDocument struct {
 	Errors []Error     `json:"-"`
 
 	resourceSlice Resources
+	fieldsets     map[string][]string
+	fullLinkage   FullLinkageMode
+
+	includedIdentities   map[Identity]struct{}
+	redactInternalErrors bool
+
+	status   int
+	omitData bool
+	nullData bool
+
 	topLevelMembers
 }
 
 // SetDataCollection is used to ensure the top level data member is encoded
 // as an empty array when it is empty
 func (doc *TopLevelDocument) SetDataCollection() {
+	doc.nullData = false
 	doc.resourceSlice = make(Resources, 0)
 }
 
-// MarshalJSON marshals a link as either an object or string depending on how
-// it has been set. If both are set, it preferes objects.
+// SetNull implements NullSetter. It removes any primary data previously set
+// so the top level data member is encoded as null.
+func (doc *TopLevelDocument) SetNull() {
+	doc.Data = nil
+	doc.resourceSlice = nil
+	doc.nullData = true
+}
+
+// MarshalJSON encodes the document. Primary data that has not been set is
+// encoded as null, or as an empty array when SetDataCollection was called.
 func (doc TopLevelDocument) MarshalJSON() ([]byte, error) {
+	doc.reportFullLinkage()
 	if len(doc.Errors) != 0 {
 		return json.Marshal(struct {
 			Errors []Error `json:"errors"`
@@ -56,17 +96,26 @@ func (doc TopLevelDocument) MarshalJSON() ([]byte, error) {
 		}{doc.Errors, doc.topLevelMembers})
 	}
 
+	if doc.fullLinkage == FullLinkagePrune {
+		doc.pruneIncluded()
+	}
+
+	if len(doc.fieldsets) != 0 {
+		if err := doc.applyFieldsets(); err != nil {
+			return nil, err
+		}
+	}
+
 	if doc.Data == nil {
+		if doc.omitData && !doc.nullData && doc.resourceSlice == nil {
+			return json.Marshal(doc.topLevelMembers)
+		}
 		if doc.resourceSlice != nil {
 			return json.Marshal(struct {
 				Data []struct{} `json:"data"`
 				topLevelMembers
 			}{[]struct{}{}, doc.topLevelMembers})
 		}
-		return json.Marshal(struct {
-			Data struct{} `json:"data"`
-			topLevelMembers
-		}{struct{}{}, doc.topLevelMembers})
 	}
 
 	return json.Marshal(struct {
@@ -75,31 +124,346 @@ func (doc TopLevelDocument) MarshalJSON() ([]byte, error) {
 	}{doc.Data, doc.topLevelMembers})
 }
 
-// UnmarshalJSON unmarshals a link as either an object or string depending on
-// how it has been encoded.
-// func (doc *TopLevelDocument) UnmarshalJSON(buf []byte) error {
-// 	return nil
-// }
+// DecodeDocument reads and decodes a top level document from r.
+func DecodeDocument(r io.Reader) (TopLevelDocument, error) {
+	var doc TopLevelDocument
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return doc, err
+	}
+	return doc, doc.UnmarshalJSON(buf)
+}
+
+// UnmarshalJSON decodes a top level document. After decoding, Data is nil
+// when the primary data is null, a *Resource when it is a single resource
+// object and Resources when it is an array. Resource attributes are kept as
+// json.RawMessage so they can be decoded later with UnmarshalAttributes.
+// Malformed documents result in an Error with a source pointer to the
+// offending member.
+func (doc *TopLevelDocument) UnmarshalJSON(buf []byte) error {
+	members, err := decodeObject(buf, "")
+	if err != nil {
+		return err
+	}
+
+	var decoded TopLevelDocument
+
+	dataBuf, hasData := members["data"]
+	errorsBuf, hasErrors := members["errors"]
+	_, hasMeta := members["meta"]
+	includedBuf, hasIncluded := members["included"]
+
+	if !hasData && !hasErrors && !hasMeta {
+		return decodingError("", "a document must contain at least one of data, errors or meta")
+	}
+	if hasData && hasErrors {
+		return decodingError("", "a document must not contain both data and errors")
+	}
+	if hasIncluded && !hasData {
+		return decodingError("/included", "a document must not contain included without data")
+	}
+
+	if hasData {
+		switch firstByte(dataBuf) {
+		case 'n':
+		case '{':
+			resource, err := decodeResource(dataBuf, "/data")
+			if err != nil {
+				return err
+			}
+			decoded.Data = &resource
+		case '[':
+			resources, err := decodeResources(dataBuf, "/data")
+			if err != nil {
+				return err
+			}
+			decoded.resourceSlice = resources
+			decoded.Data = resources
+		default:
+			return decodingError("/data", "data must be null, an object or an array")
+		}
+	}
+
+	if hasErrors {
+		if firstByte(errorsBuf) != '[' {
+			return decodingError("/errors", "errors must be an array")
+		}
+		if err := json.Unmarshal(errorsBuf, &decoded.Errors); err != nil {
+			return decodingError("/errors", err.Error())
+		}
+	}
+
+	if hasIncluded {
+		if decoded.Included, err = decodeResources(includedBuf, "/included"); err != nil {
+			return err
+		}
+	}
+
+	for _, member := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"jsonapi", &decoded.JSONAPI},
+		{"links", &decoded.Links},
+		{"meta", &decoded.Meta},
+	} {
+		memberBuf, ok := members[member.name]
+		if !ok {
+			continue
+		}
+		if firstByte(memberBuf) != '{' {
+			return decodingError("/"+member.name, member.name+" must be an object")
+		}
+		if err := json.Unmarshal(memberBuf, member.v); err != nil {
+			return decodingError("/"+member.name, err.Error())
+		}
+	}
+
+	*doc = decoded
+	return nil
+}
+
+// UnmarshalJSON decodes a resource object keeping its attributes as a
+// json.RawMessage.
+func (resource *Resource) UnmarshalJSON(buf []byte) error {
+	decoded, err := decodeResource(buf, "")
+	if err != nil {
+		return err
+	}
+	*resource = decoded
+	return nil
+}
+
+// UnmarshalAttributes decodes the resource's attributes into v.
+func (resource Resource) UnmarshalAttributes(v interface{}) error {
+	switch attributes := resource.Attributes.(type) {
+	case nil:
+		return nil
+	case json.RawMessage:
+		return json.Unmarshal(attributes, v)
+	default:
+		buf, err := json.Marshal(attributes)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(buf, v)
+	}
+}
+
+func decodeResources(buf []byte, pointer string) (Resources, error) {
+	var elements []json.RawMessage
+	if firstByte(buf) != '[' {
+		return nil, decodingError(pointer, "must be an array of resource objects")
+	}
+	if err := json.Unmarshal(buf, &elements); err != nil {
+		return nil, decodingError(pointer, err.Error())
+	}
+	resources := make(Resources, 0, len(elements))
+	for i, element := range elements {
+		resource, err := decodeResource(element, fmt.Sprintf("%s/%d", pointer, i))
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+func decodeResource(buf []byte, pointer string) (Resource, error) {
+	var resource Resource
+
+	members, err := decodeObject(buf, pointer)
+	if err != nil {
+		return resource, err
+	}
+
+	if err := decodeString(members, "type", pointer, &resource.Type); err != nil {
+		return resource, err
+	}
+	if resource.Type == "" {
+		return resource, decodingError(pointer+"/type", "a resource object must have a type")
+	}
+	if err := decodeString(members, "id", pointer, &resource.ID); err != nil {
+		return resource, err
+	}
+
+	if attributes, ok := members["attributes"]; ok && firstByte(attributes) != 'n' {
+		if firstByte(attributes) != '{' {
+			return resource, decodingError(pointer+"/attributes", "attributes must be an object")
+		}
+		resource.Attributes = json.RawMessage(attributes)
+	}
+
+	if relationships, ok := members["relationships"]; ok {
+		if resource.Relationships, err = decodeRelationships(relationships, pointer+"/relationships"); err != nil {
+			return resource, err
+		}
+	}
+
+	if links, ok := members["links"]; ok {
+		if err := json.Unmarshal(links, &resource.Links); err != nil {
+			return resource, decodingError(pointer+"/links", err.Error())
+		}
+	}
+	if meta, ok := members["meta"]; ok {
+		if err := json.Unmarshal(meta, &resource.Meta); err != nil {
+			return resource, decodingError(pointer+"/meta", err.Error())
+		}
+	}
+
+	return resource, nil
+}
+
+func decodeRelationships(buf []byte, pointer string) (Relationships, error) {
+	members, err := decodeObject(buf, pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	relationships := make(Relationships, len(members))
+	for _, name := range names {
+		relPointer := pointer + "/" + name
+		relMembers, err := decodeObject(members[name], relPointer)
+		if err != nil {
+			return nil, err
+		}
+
+		var rel Relationship
+		if data, ok := relMembers["data"]; ok {
+			if rel.Data, err = decodeLinkage(data, relPointer+"/data"); err != nil {
+				return nil, err
+			}
+		}
+		if links, ok := relMembers["links"]; ok {
+			if err := json.Unmarshal(links, &rel.Links); err != nil {
+				return nil, decodingError(relPointer+"/links", err.Error())
+			}
+		}
+		if meta, ok := relMembers["meta"]; ok {
+			if err := json.Unmarshal(meta, &rel.Meta); err != nil {
+				return nil, decodingError(relPointer+"/meta", err.Error())
+			}
+		}
+		relationships[name] = rel
+	}
+	return relationships, nil
+}
+
+func decodeLinkage(buf []byte, pointer string) (ResourceLinkage, error) {
+	var linkage ResourceLinkage
+	switch firstByte(buf) {
+	case 'n':
+		return linkage, nil
+	case '{':
+		identity, err := decodeIdentity(buf, pointer)
+		linkage.ToOne = identity
+		return linkage, err
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(buf, &elements); err != nil {
+			return linkage, decodingError(pointer, err.Error())
+		}
+		linkage.ToMany = make([]Identity, 0, len(elements))
+		for i, element := range elements {
+			identity, err := decodeIdentity(element, fmt.Sprintf("%s/%d", pointer, i))
+			if err != nil {
+				return linkage, err
+			}
+			linkage.ToMany = append(linkage.ToMany, identity)
+		}
+		return linkage, nil
+	default:
+		return linkage, decodingError(pointer, "resource linkage must be null, an object or an array")
+	}
+}
+
+func decodeIdentity(buf []byte, pointer string) (Identity, error) {
+	var identity Identity
+	members, err := decodeObject(buf, pointer)
+	if err != nil {
+		return identity, err
+	}
+	if err := decodeString(members, "type", pointer, &identity.Type); err != nil {
+		return identity, err
+	}
+	if err := decodeString(members, "id", pointer, &identity.ID); err != nil {
+		return identity, err
+	}
+	if identity.Type == "" || identity.ID == "" {
+		return identity, decodingError(pointer, "a resource identifier object must have a type and id")
+	}
+	return identity, nil
+}
+
+func decodeObject(buf []byte, pointer string) (map[string]json.RawMessage, error) {
+	if firstByte(buf) != '{' {
+		return nil, decodingError(pointer, "must be an object")
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &members); err != nil {
+		return nil, decodingError(pointer, err.Error())
+	}
+	return members, nil
+}
+
+func decodeString(members map[string]json.RawMessage, name, pointer string, s *string) error {
+	buf, ok := members[name]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(buf, s); err != nil || firstByte(buf) != '"' {
+		return decodingError(pointer+"/"+name, name+" must be a string")
+	}
+	return nil
+}
+
+func decodingError(pointer, detail string) error {
+	err := Error{Status: http.StatusBadRequest, Detail: detail}
+	if pointer != "" {
+		err.Source = PointerSource(pointer)
+	}
+	return err
+}
+
+func firstByte(buf []byte) byte {
+	buf = bytes.TrimSpace(buf)
+	if len(buf) == 0 {
+		return 0
+	}
+	return buf[0]
+}
 
 // SetData implements DataSetter.
 func (doc *TopLevelDocument) SetData(resourceType, id string, attributes interface{}, relationships Relationships, links Links, meta Meta) error {
 	doc.resourceSlice = nil
+	doc.nullData = false
 	doc.Data = &Resource{
 		ID:            id,
 		Type:          resourceType,
 		Attributes:    attributes,
 		Relationships: relationships,
+		Links:         links,
+		Meta:          meta,
 	}
 	return nil
 }
 
 // AppendData implements DataAppender.
 func (doc *TopLevelDocument) AppendData(resourceType, id string, attributes interface{}, relationships Relationships, links Links, meta Meta) error {
+	doc.nullData = false
 	doc.resourceSlice = append(doc.resourceSlice, Resource{
 		ID:            id,
 		Type:          resourceType,
 		Attributes:    attributes,
 		Relationships: relationships,
+		Links:         links,
+		Meta:          meta,
 	})
 	doc.Data = doc.resourceSlice
 	return nil
@@ -115,47 +479,164 @@ func (doc *TopLevelDocument) AppendIdentity(resourceType, id string) error {
 	return doc.AppendData(resourceType, id, nil, nil, nil, nil)
 }
 
-type httpStatuser interface {
-	HTTPStatus() int
+// SetLinks implements LinksSetter. Links with the same name replace those
+// previously set.
+func (doc *TopLevelDocument) SetLinks(links Links) {
+	if doc.Links == nil {
+		doc.Links = make(Links, len(links))
+	}
+	for name, link := range links {
+		doc.Links[name] = link
+	}
 }
 
-// AppendError implements ErrorAppender appending the error as
-// the detail member of an Error
-func (doc *TopLevelDocument) AppendError(err error) {
-	if err != nil {
-		error, ok := err.(Error)
+// SetMeta implements MetaSetter. Members with the same name replace those
+// previously set.
+func (doc *TopLevelDocument) SetMeta(meta Meta) {
+	if doc.Meta == nil {
+		doc.Meta = make(Meta, len(meta))
+	}
+	for name, value := range meta {
+		doc.Meta[name] = value
+	}
+}
 
-		if !ok {
-			error.Detail = err.Error()
-		}
+// applyMediaTypeParams lists the applied extensions and profiles in the
+// top level jsonapi object.
+func (doc *TopLevelDocument) applyMediaTypeParams(params MediaTypeParams) {
+	if len(params.Ext) == 0 && len(params.Profile) == 0 {
+		return
+	}
+	if doc.JSONAPI == nil {
+		doc.JSONAPI = &JSONAPIObject{}
+	}
+	doc.JSONAPI.Ext = params.Ext
+	doc.JSONAPI.Profile = params.Profile
+}
+
+// SetStatus implements StatusSetter.
+func (doc *TopLevelDocument) SetStatus(status int) {
+	doc.status = status
+}
+
+// SetJSONAPIObject implements JSONAPIObjectSetter.
+func (doc *TopLevelDocument) SetJSONAPIObject(obj JSONAPIObject) {
+	doc.JSONAPI = &obj
+}
+
+type (
+	httpStatuser interface {
+		HTTPStatus() int
+	}
+
+	errorCoder interface {
+		ErrorCode() string
+	}
+
+	errorTitler interface {
+		ErrorTitle() string
+	}
 
-		if statuser, ok := err.(httpStatuser); ok {
-			error.Status = statuser.HTTPStatus()
+	errorSourcer interface {
+		ErrorSource() *ErrorSource
+	}
+
+	errorMetaer interface {
+		ErrorMeta() Meta
+	}
+)
+
+// AppendError implements ErrorAppender. If err is, or wraps, an Error it is
+// appended as is. Errors joined with errors.Join are appended individually.
+// Other errors are appended with err.Error() as the detail member; they may
+// contribute other members by implementing any of
+//
+//	HTTPStatus() int
+//	ErrorCode() string
+//	ErrorTitle() string
+//	ErrorSource() *ErrorSource
+//	ErrorMeta() Meta
+func (doc *TopLevelDocument) AppendError(err error) {
+	if err == nil {
+		return
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			doc.AppendError(err)
 		}
+		return
+	}
 
+	var error Error
+	if errors.As(err, &error) {
+		error.Status = error.HTTPStatus()
 		doc.Errors = append(doc.Errors, error)
+		return
 	}
+
+	error.Detail = err.Error()
+
+	var statuser httpStatuser
+	if errors.As(err, &statuser) {
+		error.Status = statuser.HTTPStatus()
+	}
+	var coder errorCoder
+	if errors.As(err, &coder) {
+		error.Code = coder.ErrorCode()
+	}
+	var titler errorTitler
+	if errors.As(err, &titler) {
+		error.Title = titler.ErrorTitle()
+	}
+	var sourcer errorSourcer
+	if errors.As(err, &sourcer) {
+		error.Source = sourcer.ErrorSource()
+	}
+	var metaer errorMetaer
+	if errors.As(err, &metaer) {
+		error.Meta = metaer.ErrorMeta()
+	}
+
+	if doc.redactInternalErrors && (error.Status == 0 || error.Status >= 500) {
+		error.Detail = http.StatusText(http.StatusInternalServerError)
+	}
+
+	doc.Errors = append(doc.Errors, error)
+}
+
+// SetInternalErrorRedaction sets whether the detail of errors appended with
+// AppendError that are not an Error, and do not have a 4XX status, is
+// replaced with a generic message so internal details are not exposed.
+func (doc *TopLevelDocument) SetInternalErrorRedaction(redact bool) {
+	doc.redactInternalErrors = redact
 }
 
-// Include implements Includer.
+// Include implements Includer. A resource with the same type and id as a
+// primary or previously included resource is not included again.
 func (doc *TopLevelDocument) Include(resourceType, id string, attributes interface{}, relationships Relationships, links Links, meta Meta) error {
+	identity := Identity{ID: id, Type: resourceType}
+	if doc.isPrimary(identity) {
+		return nil
+	}
+	if doc.includedIdentities == nil || len(doc.includedIdentities) != len(doc.Included) {
+		doc.includedIdentities = make(map[Identity]struct{}, len(doc.Included))
+		for _, resource := range doc.Included {
+			doc.includedIdentities[resource.identity()] = struct{}{}
+		}
+	}
+	if _, included := doc.includedIdentities[identity]; included {
+		return nil
+	}
+	doc.includedIdentities[identity] = struct{}{}
+
 	doc.Included = append(doc.Included, Resource{
 		ID:            id,
 		Type:          resourceType,
 		Attributes:    attributes,
 		Relationships: relationships,
+		Links:         links,
+		Meta:          meta,
 	})
 	return nil
 }
-
-// func UnmarshalAttributes(req *http.Request, attributes interface{}) {
-//
-// }
-//
-// func UnmarshalToOneRelationship(req *http.Request, relationshipName string) {
-//
-// }
-//
-// func UnmarshalToManyRelationship(req *http.Request, relationshipName string) []Relationships {
-//
-// }
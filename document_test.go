@@ -1,8 +1,12 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -95,3 +99,446 @@ func Test_TopLevelDocument(t *testing.T) {
 		// }
 	})
 }
+
+func Test_TopLevelDocument_PrimaryData(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		set    func(doc *TopLevelDocument)
+		result string
+	}{
+		{"no data is set", func(doc *TopLevelDocument) {}, `{"data":null}`},
+		{"null is set", func(doc *TopLevelDocument) { doc.SetNull() }, `{"data":null}`},
+		{"null is set after data", func(doc *TopLevelDocument) {
+			doc.SetIdentity("people", "9")
+			doc.SetNull()
+		}, `{"data":null}`},
+		{"an empty collection is set", func(doc *TopLevelDocument) { doc.SetDataCollection() }, `{"data":[]}`},
+		{"an empty collection is set after null", func(doc *TopLevelDocument) {
+			doc.SetNull()
+			doc.SetDataCollection()
+		}, `{"data":[]}`},
+		{"a single resource is set", func(doc *TopLevelDocument) { doc.SetIdentity("people", "9") }, `{"data":{"id":"9","type":"people"}}`},
+		{"a single resource is set after null", func(doc *TopLevelDocument) {
+			doc.SetNull()
+			doc.SetIdentity("people", "9")
+		}, `{"data":{"id":"9","type":"people"}}`},
+		{"a collection is set", func(doc *TopLevelDocument) {
+			doc.AppendIdentity("people", "9")
+			doc.AppendIdentity("people", "10")
+		}, `{"data":[{"id":"9","type":"people"},{"id":"10","type":"people"}]}`},
+		{"null is set with meta", func(doc *TopLevelDocument) {
+			doc.SetNull()
+			doc.SetMeta(Meta{"count": 0})
+		}, `{"data":null,"meta":{"count":0}}`},
+	} {
+		t.Run("when "+tt.name, func(t *testing.T) {
+			var doc TopLevelDocument
+			tt.set(&doc)
+
+			buf, err := json.Marshal(doc)
+			if err != nil {
+				t.Error("it should not return an error when marshalling")
+				t.Log(err)
+			}
+			if string(buf) != tt.result {
+				t.Error("it should encode the expected primary data")
+				t.Log(string(buf))
+			}
+		})
+	}
+}
+
+func Test_TopLevelDocument_LinksAndMeta(t *testing.T) {
+	selfLink := Links{"self": Link{String: "/articles/1"}}
+	meta := Meta{"permissions": "read"}
+
+	roundTrip := func(t *testing.T, doc TopLevelDocument, v interface{}) {
+		t.Helper()
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(buf, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustHaveLinksAndMeta := func(t *testing.T, res Resource) {
+		t.Helper()
+		if res.Links["self"].String != "/articles/1" {
+			t.Error("it should encode the resource links")
+			t.Log(res.Links)
+		}
+		if res.Meta["permissions"] != "read" {
+			t.Error("it should encode the resource meta")
+			t.Log(res.Meta)
+		}
+	}
+
+	t.Run("when data is set", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetData("articles", "1", nil, nil, selfLink, meta)
+
+		var decoded struct {
+			Data Resource `json:"data"`
+		}
+		roundTrip(t, doc, &decoded)
+		mustHaveLinksAndMeta(t, decoded.Data)
+	})
+
+	t.Run("when data is appended", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.AppendData("articles", "1", nil, nil, selfLink, meta)
+
+		var decoded struct {
+			Data Resources `json:"data"`
+		}
+		roundTrip(t, doc, &decoded)
+		if len(decoded.Data) != 1 {
+			t.Fatal("it should encode one resource")
+		}
+		mustHaveLinksAndMeta(t, decoded.Data[0])
+	})
+
+	t.Run("when a resource is included", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetIdentity("comments", "5")
+		doc.Include("articles", "1", nil, nil, selfLink, meta)
+
+		var decoded struct {
+			Included Resources `json:"included"`
+		}
+		roundTrip(t, doc, &decoded)
+		if len(decoded.Included) != 1 {
+			t.Fatal("it should encode one included resource")
+		}
+		mustHaveLinksAndMeta(t, decoded.Included[0])
+	})
+
+	t.Run("when no links or meta are passed", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetData("articles", "1", nil, nil, nil, nil)
+
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != `{"data":{"id":"1","type":"articles"}}` {
+			t.Error("it should omit the links and meta members")
+			t.Log(string(buf))
+		}
+	})
+}
+
+func Test_TopLevelDocument_TopLevelMembers(t *testing.T) {
+	t.Run("when links, meta and the jsonapi object are set", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetDataCollection()
+		doc.SetLinks(Links{"self": Link{String: "/articles?page[number]=2"}, "next": Link{String: "/articles?page[number]=3"}})
+		doc.SetLinks(Links{"next": Link{String: "/articles?page[number]=4"}})
+		doc.SetMeta(Meta{"total": 30})
+		doc.SetJSONAPIObject(JSONAPIObject{Version: "1.1", Profile: []string{"https://example.com/profile"}})
+
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := `{"data":[],"jsonapi":{"version":"1.1","profile":["https://example.com/profile"]},"links":{"next":"/articles?page[number]=4","self":"/articles?page[number]=2"},"meta":{"total":30}}`
+		if string(buf) != expected {
+			t.Error("it should encode the top level members")
+			t.Log(string(buf))
+		}
+	})
+
+	t.Run("when errors are appended", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetJSONAPIObject(JSONAPIObject{Version: "1.1"})
+		doc.SetMeta(Meta{"request": "abc"})
+		doc.AppendError(errors.New("some error"))
+
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := `{"errors":[{"detail":"some error"}],"jsonapi":{"version":"1.1"},"meta":{"request":"abc"}}`
+		if string(buf) != expected {
+			t.Error("it should encode the top level members alongside errors")
+			t.Log(string(buf))
+		}
+	})
+}
+
+func Test_TopLevelDocument_UnmarshalJSON(t *testing.T) {
+	t.Run("when data is null", func(t *testing.T) {
+		var doc TopLevelDocument
+		if err := json.Unmarshal([]byte(`{"data": null, "meta": {"count": 0}}`), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if doc.Data != nil {
+			t.Error("it should leave data nil")
+			t.Log(doc.Data)
+		}
+		if doc.Meta["count"] != float64(0) {
+			t.Error("it should decode meta")
+			t.Log(doc.Meta)
+		}
+	})
+
+	t.Run("when data is a single resource", func(t *testing.T) {
+		var doc TopLevelDocument
+		buf := []byte(`{
+			"data": {
+				"type": "articles", "id": "1",
+				"attributes": {"title": "Rails is Omakase"},
+				"relationships": {
+					"author": {"data": {"type": "people", "id": "9"}, "links": {"related": "/articles/1/author"}},
+					"tags": {"data": []}
+				},
+				"links": {"self": "/articles/1"}
+			},
+			"included": [{"type": "people", "id": "9", "attributes": {"name": "Dan"}}],
+			"links": {"self": {"href": "/articles/1"}},
+			"jsonapi": {"version": "1.1"}
+		}`)
+		if err := json.Unmarshal(buf, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		resource, ok := doc.Data.(*Resource)
+		if !ok {
+			t.Fatalf("it should decode data as a *Resource not %T", doc.Data)
+		}
+		if resource.ID != "1" || resource.Type != "articles" {
+			t.Error("it should decode the identity")
+			t.Log(resource)
+		}
+
+		var attributes struct {
+			Title string `json:"title"`
+		}
+		if err := resource.UnmarshalAttributes(&attributes); err != nil || attributes.Title != "Rails is Omakase" {
+			t.Error("it should decode the attributes lazily")
+			t.Log(err, attributes)
+		}
+
+		author := resource.Relationships["author"]
+		if author.Data.ToOne != (Identity{ID: "9", Type: "people"}) || author.Links["related"].String != "/articles/1/author" {
+			t.Error("it should decode the to-one relationship")
+			t.Log(author)
+		}
+		if tags := resource.Relationships["tags"]; !tags.Data.IsToMany() || len(tags.Data.ToMany) != 0 {
+			t.Error("it should decode the empty to-many relationship")
+			t.Log(tags)
+		}
+		if resource.Links["self"].String != "/articles/1" {
+			t.Error("it should decode the resource links")
+		}
+		if len(doc.Included) != 1 || doc.Included[0].ID != "9" {
+			t.Error("it should decode the included resources")
+			t.Log(doc.Included)
+		}
+		if doc.Links["self"].Object.HREF != "/articles/1" {
+			t.Error("it should decode the top level links")
+		}
+		if doc.JSONAPI == nil || doc.JSONAPI.Version != "1.1" {
+			t.Error("it should decode the jsonapi object")
+		}
+	})
+
+	t.Run("when data is an array", func(t *testing.T) {
+		var doc TopLevelDocument
+		if err := json.Unmarshal([]byte(`{"data": [{"type": "tags", "id": "2"}, {"type": "tags", "id": "3"}]}`), &doc); err != nil {
+			t.Fatal(err)
+		}
+		resources, ok := doc.Data.(Resources)
+		if !ok || len(resources) != 2 {
+			t.Fatalf("it should decode data as Resources not %T", doc.Data)
+		}
+
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != `{"data":[{"id":"2","type":"tags"},{"id":"3","type":"tags"}]}` {
+			t.Error("it should encode the decoded document")
+			t.Log(string(buf))
+		}
+	})
+
+	t.Run("when data is an empty array", func(t *testing.T) {
+		doc, err := DecodeDocument(bytes.NewReader([]byte(`{"data": []}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != `{"data":[]}` {
+			t.Error("it should keep data as an empty array")
+			t.Log(string(buf))
+		}
+	})
+
+	t.Run("when errors are decoded", func(t *testing.T) {
+		var doc TopLevelDocument
+		if err := json.Unmarshal([]byte(`{"errors": [{"status": "404", "title": "Not Found"}]}`), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Errors) != 1 || doc.Errors[0].Status != 404 || doc.Errors[0].Title != "Not Found" {
+			t.Error("it should decode the errors")
+			t.Log(doc.Errors)
+		}
+	})
+
+	t.Run("when the document is malformed", func(t *testing.T) {
+		for _, tt := range []struct {
+			doc, pointer string
+		}{
+			{`[]`, ""},
+			{`{}`, ""},
+			{`{"data": null, "errors": []}`, ""},
+			{`{"meta": {}, "included": []}`, "/included"},
+			{`{"data": 1}`, "/data"},
+			{`{"data": {"id": "1"}}`, "/data/type"},
+			{`{"data": {"type": 1}}`, "/data/type"},
+			{`{"data": [{"type": "a", "id": "1"}, {"id": "2"}]}`, "/data/1/type"},
+			{`{"data": {"type": "a", "attributes": []}}`, "/data/attributes"},
+			{`{"data": {"type": "a", "relationships": {"b": {"data": {"type": "c"}}}}}`, "/data/relationships/b/data"},
+			{`{"data": {"type": "a", "relationships": {"b": {"data": [{"id": "1", "type": "c"}, 2]}}}}`, "/data/relationships/b/data/1"},
+			{`{"data": null, "included": [{"id": "1"}]}`, "/included/0/type"},
+			{`{"errors": {}}`, "/errors"},
+			{`{"meta": []}`, "/meta"},
+		} {
+			var doc TopLevelDocument
+			err := json.Unmarshal([]byte(tt.doc), &doc)
+			if err == nil {
+				t.Error("it should return an error")
+				t.Log(tt.doc)
+				continue
+			}
+			var decodingErr Error
+			if e, ok := err.(Error); ok {
+				decodingErr = e
+			}
+			var pointer string
+			if decodingErr.Source != nil {
+				pointer = decodingErr.Source.Pointer
+			}
+			if decodingErr.Status != http.StatusBadRequest || pointer != tt.pointer {
+				t.Error("it should return an error pointing to the malformed member")
+				t.Log(tt.doc)
+				t.Log(err)
+			}
+		}
+	})
+}
+
+func Test_TopLevelDocument_Include(t *testing.T) {
+	t.Run("when a resource is included twice", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetData("articles", "1", nil, nil, nil, nil)
+		doc.Include("people", "9", map[string]string{"name": "Dan"}, nil, nil, nil)
+		doc.Include("people", "9", map[string]string{"name": "Daniel"}, nil, nil, nil)
+		doc.Include("people", "10", nil, nil, nil, nil)
+
+		if len(doc.Included) != 2 {
+			t.Fatal("it should only include the resource once")
+		}
+		if name := doc.Included[0].Attributes.(map[string]string)["name"]; name != "Dan" {
+			t.Error("it should keep the first included resource")
+			t.Log(name)
+		}
+	})
+
+	t.Run("when a primary resource is included", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.AppendData("articles", "1", nil, nil, nil, nil)
+		doc.AppendData("articles", "2", nil, nil, nil, nil)
+		doc.Include("articles", "2", nil, nil, nil, nil)
+
+		if len(doc.Included) != 0 {
+			t.Error("it should not include primary resources")
+			t.Log(doc.Included)
+		}
+	})
+}
+
+type detailedError struct{}
+
+func (detailedError) Error() string             { return "quota exceeded" }
+func (detailedError) HTTPStatus() int           { return http.StatusTooManyRequests }
+func (detailedError) ErrorCode() string         { return "quota" }
+func (detailedError) ErrorTitle() string        { return "Quota Exceeded" }
+func (detailedError) ErrorSource() *ErrorSource { return HeaderSource("Authorization") }
+func (detailedError) ErrorMeta() Meta           { return Meta{"retry": 60} }
+
+func Test_TopLevelDocument_AppendError(t *testing.T) {
+	t.Run("when a wrapped Error is appended", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.AppendError(fmt.Errorf("fetching article: %w", ErrNotFound("articles", "1")))
+
+		if len(doc.Errors) != 1 || doc.Errors[0].Code != CodeNotFound || doc.Errors[0].Status != http.StatusNotFound {
+			t.Error("it should append the wrapped Error")
+			t.Log(doc.Errors)
+		}
+	})
+
+	t.Run("when joined errors are appended", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.AppendError(errors.Join(
+			ErrInvalidAttribute("/data/attributes/title", "must not be empty"),
+			fmt.Errorf("body: %w", ErrInvalidAttribute("/data/attributes/body", "must not be empty")),
+			errors.New("some error"),
+		))
+
+		if len(doc.Errors) != 3 {
+			t.Fatal("it should append each error")
+		}
+		if doc.Errors[1].Source.Pointer != "/data/attributes/body" || doc.Errors[2].Detail != "some error" {
+			t.Error("it should append each error in order")
+			t.Log(doc.Errors)
+		}
+	})
+
+	t.Run("when an error contributes members", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.AppendError(fmt.Errorf("wrapped: %w", detailedError{}))
+
+		expected := Error{
+			Status: http.StatusTooManyRequests,
+			Code:   "quota",
+			Title:  "Quota Exceeded",
+			Detail: "wrapped: quota exceeded",
+			Source: HeaderSource("Authorization"),
+			Meta:   Meta{"retry": 60},
+		}
+		if len(doc.Errors) != 1 || !reflect.DeepEqual(doc.Errors[0], expected) {
+			t.Error("it should use the contributed members")
+			t.Log(doc.Errors)
+		}
+	})
+
+	t.Run("when internal errors are redacted", func(t *testing.T) {
+		var doc TopLevelDocument
+		doc.SetInternalErrorRedaction(true)
+		doc.AppendError(errors.New("pq: connection refused"))
+		doc.AppendError(detailedError{})
+		doc.AppendError(Error{Status: http.StatusInternalServerError, Detail: "deliberate detail"})
+
+		if doc.Errors[0].Detail != "Internal Server Error" {
+			t.Error("it should redact the detail of internal errors")
+			t.Log(doc.Errors[0])
+		}
+		if doc.Errors[1].Detail != "quota exceeded" {
+			t.Error("it should not redact errors with a 4XX status")
+			t.Log(doc.Errors[1])
+		}
+		if doc.Errors[2].Detail != "deliberate detail" {
+			t.Error("it should not redact jsonapi Errors")
+			t.Log(doc.Errors[2])
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // Error objects provide additional information about problems encountere while
@@ -35,10 +36,16 @@ type Error struct {
 	// the problem. Like title, this field’s value can be localized.
 	Detail string `json:"detail,omitempty"`
 
-	// Source may be an object containing references to the source of the error,
-	// optionally including any of the following members:
-	Source string `json:"source,omitempty"`
+	// Source may be an object containing references to the source of the error.
+	Source *ErrorSource `json:"source,omitempty"`
 
+	// Meta may be a meta object containing non-standard meta-information about
+	// the error.
+	Meta Meta `json:"meta,omitempty"`
+}
+
+// ErrorSource represents the source member of an error object.
+type ErrorSource struct {
 	// Pointer may be a JSON Pointer [RFC6901] to the associated entity in the
 	// request document [e.g. "/data" for a primary data object, or
 	// "/data/attributes/title" for a specific attribute].
@@ -48,9 +55,80 @@ type Error struct {
 	// error.
 	Parameter string `json:"parameter,omitempty"`
 
-	// Meta may be a meta object containing non-standard meta-information about
-	// the error.
-	Meta Meta `json:"meta,omitempty"`
+	// Header may be a string indicating the name of a single request header
+	// which caused the error.
+	Header string `json:"header,omitempty"`
+}
+
+// PointerSource returns an error source referring to the member of the
+// request document at pointer.
+func PointerSource(pointer string) *ErrorSource {
+	return &ErrorSource{Pointer: pointer}
+}
+
+// AttributeSource returns an error source referring to an attribute of the
+// primary data in the request document.
+func AttributeSource(name string) *ErrorSource {
+	return PointerSource("/data/attributes/" + name)
+}
+
+// RelationshipSource returns an error source referring to a relationship of
+// the primary data in the request document.
+func RelationshipSource(name string) *ErrorSource {
+	return PointerSource("/data/relationships/" + name)
+}
+
+// ParameterSource returns an error source referring to a query parameter.
+func ParameterSource(parameter string) *ErrorSource {
+	return &ErrorSource{Parameter: parameter}
+}
+
+// HeaderSource returns an error source referring to a request header.
+func HeaderSource(header string) *ErrorSource {
+	return &ErrorSource{Header: header}
+}
+
+// UnmarshalJSON decodes an error object. Errors encoded by earlier versions
+// of this package, with pointer and parameter members next to source, are
+// decoded into Source.
+func (error *Error) UnmarshalJSON(buf []byte) error {
+	type errorMembers Error
+	var decoded struct {
+		errorMembers
+		Source    json.RawMessage `json:"source,omitempty"`
+		Pointer   string          `json:"pointer,omitempty"`
+		Parameter string          `json:"parameter,omitempty"`
+	}
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		return err
+	}
+	*error = Error(decoded.errorMembers)
+
+	var source ErrorSource
+	switch firstByte(decoded.Source) {
+	case '{':
+		if err := json.Unmarshal(decoded.Source, &source); err != nil {
+			return err
+		}
+	case '"':
+		var pointer string
+		if err := json.Unmarshal(decoded.Source, &pointer); err != nil {
+			return err
+		}
+		if strings.HasPrefix(pointer, "/") {
+			source.Pointer = pointer
+		}
+	}
+	if source.Pointer == "" {
+		source.Pointer = decoded.Pointer
+	}
+	if source.Parameter == "" {
+		source.Parameter = decoded.Parameter
+	}
+	if source != (ErrorSource{}) {
+		error.Source = &source
+	}
+	return nil
 }
 
 // HTTPStatus returns a HTTP status code for an error
@@ -71,32 +149,46 @@ func (error Error) Error() string {
 	return string(buf)
 }
 
-// ErrorsPolicy calculates the appropriate HTTP response status code.
+// ErrorsPolicy calculates the appropriate HTTP response status code using
+// the most generally applicable status. A single distinct status is returned
+// as is. Several distinct 4XX statuses result in 400 (bad request) and any
+// other mix of statuses results in 500 (internal server error). Errors
+// without a status, or with a status that is not an error, count as 500.
 // https://jsonapi.org/format/#errors-processing
 func ErrorsPolicy(errors []Error) int {
+	if len(errors) == 0 {
+		return http.StatusOK
+	}
+
 	var (
-		status             = http.StatusOK
+		status             int
+		distinct           bool
 		count5XX, count4XX int
 	)
 	for _, err := range errors {
-		if err.Status > 500 {
+		errStatus := err.HTTPStatus()
+		if errStatus < 400 || errStatus > 599 {
+			errStatus = http.StatusInternalServerError
+		}
+
+		if errStatus >= 500 {
 			count5XX++
-		} else if err.Status > 400 {
+		} else {
 			count4XX++
 		}
 
-		if err.Status > status {
-			status = err.Status
+		if status != 0 && status != errStatus {
+			distinct = true
 		}
+		status = errStatus
 	}
 
-	if count4XX > 1 {
-		status = 400
-	}
-
-	if count5XX > 1 {
-		status = 500
+	switch {
+	case !distinct:
+		return status
+	case count5XX == 0:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
 	}
-
-	return status
 }
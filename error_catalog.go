@@ -0,0 +1,182 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Codes of the errors in the catalog. They do not change from occurrence to
+// occurrence so they may be used to recognize an error with errors.Is, for
+// example errors.Is(err, Error{Code: CodeNotFound}).
+const (
+	CodeTypeMismatch       = "type-mismatch"
+	CodeClientIDForbidden  = "client-id-forbidden"
+	CodeIDConflict         = "id-conflict"
+	CodeNotFound           = "not-found"
+	CodeInvalidAttribute   = "invalid-attribute"
+	CodeUnsupportedInclude = "unsupported-include"
+	CodeUnsupportedSort    = "unsupported-sort"
+	CodeUnsupportedMedia   = "unsupported-media-type"
+	CodeNotAcceptable      = "not-acceptable"
+	CodeMethodNotAllowed   = "method-not-allowed"
+	CodeUnsupportedAction  = "unsupported-action"
+	CodeInternal           = "internal-error"
+)
+
+// Is reports whether target is an Error with the same Code. It allows
+// errors.Is to recognize errors from the catalog, even when wrapped. Errors
+// without a code are never considered equal.
+func (error Error) Is(target error) bool {
+	t, ok := target.(Error)
+	if !ok {
+		return false
+	}
+	return error.Code != "" && error.Code == t.Code
+}
+
+// ErrTypeMismatch returns the error to respond with when the type of the
+// primary data in a request does not match the endpoint's resource type.
+func ErrTypeMismatch(resourceType string) Error {
+	return Error{
+		Status: http.StatusConflict,
+		Code:   CodeTypeMismatch,
+		Title:  "Type Mismatch",
+		Detail: fmt.Sprintf("%q is not among the type(s) that constitute the collection represented by the endpoint", resourceType),
+		Source: PointerSource("/data/type"),
+	}
+}
+
+// ErrClientIDForbidden returns the error to respond with when a request to
+// create a resource includes an id and client generated IDs are not
+// supported.
+func ErrClientIDForbidden() Error {
+	return Error{
+		Status: http.StatusForbidden,
+		Code:   CodeClientIDForbidden,
+		Title:  "Client Generated ID Forbidden",
+		Detail: "client generated IDs are not supported",
+		Source: PointerSource("/data/id"),
+	}
+}
+
+// ErrIDConflict returns the error to respond with when a request to create a
+// resource includes a client generated id that already exists.
+func ErrIDConflict(id string) Error {
+	return Error{
+		Status: http.StatusConflict,
+		Code:   CodeIDConflict,
+		Title:  "ID Conflict",
+		Detail: fmt.Sprintf("a resource with id %q already exists", id),
+		Source: PointerSource("/data/id"),
+	}
+}
+
+// ErrNotFound returns the error to respond with when a requested resource
+// does not exist.
+func ErrNotFound(resourceType, id string) Error {
+	return Error{
+		Status: http.StatusNotFound,
+		Code:   CodeNotFound,
+		Title:  "Not Found",
+		Detail: fmt.Sprintf("%s %q not found", resourceType, id),
+	}
+}
+
+// ErrInvalidAttribute returns the error to respond with when a member of the
+// request document at pointer, such as "/data/attributes/title", is not
+// valid. detail should explain why.
+func ErrInvalidAttribute(pointer, detail string) Error {
+	return Error{
+		Status: http.StatusUnprocessableEntity,
+		Code:   CodeInvalidAttribute,
+		Title:  "Invalid Attribute",
+		Detail: detail,
+		Source: PointerSource(pointer),
+	}
+}
+
+// ErrUnsupportedInclude returns the error to respond with when the server
+// does not support including the relationship path.
+func ErrUnsupportedInclude(relationshipPath string) Error {
+	return Error{
+		Status: http.StatusBadRequest,
+		Code:   CodeUnsupportedInclude,
+		Title:  "Unsupported Include",
+		Detail: fmt.Sprintf("including %q is not supported", relationshipPath),
+		Source: ParameterSource("include"),
+	}
+}
+
+// ErrUnsupportedSort returns the error to respond with when the server does
+// not support sorting by field.
+func ErrUnsupportedSort(field string) Error {
+	return Error{
+		Status: http.StatusBadRequest,
+		Code:   CodeUnsupportedSort,
+		Title:  "Unsupported Sort",
+		Detail: fmt.Sprintf("sorting by %q is not supported", field),
+		Source: ParameterSource("sort"),
+	}
+}
+
+// ErrUnsupportedMediaType returns the error to respond with when the
+// Content-Type of a request is not the JSON:API media type or has parameters
+// the server does not support. detail should explain why.
+func ErrUnsupportedMediaType(detail string) Error {
+	return Error{
+		Status: http.StatusUnsupportedMediaType,
+		Code:   CodeUnsupportedMedia,
+		Title:  "Unsupported Media Type",
+		Detail: detail,
+		Source: HeaderSource("Content-Type"),
+	}
+}
+
+// ErrNotAcceptable returns the error to respond with when the Accept header
+// of a request does not allow a JSON:API response the server supports.
+// detail should explain why.
+func ErrNotAcceptable(detail string) Error {
+	return Error{
+		Status: http.StatusNotAcceptable,
+		Code:   CodeNotAcceptable,
+		Title:  "Not Acceptable",
+		Detail: detail,
+		Source: HeaderSource("Accept"),
+	}
+}
+
+// ErrMethodNotAllowed returns the error to respond with when a request
+// method is not supported for the requested path.
+func ErrMethodNotAllowed(method string) Error {
+	return Error{
+		Status: http.StatusMethodNotAllowed,
+		Code:   CodeMethodNotAllowed,
+		Title:  "Method Not Allowed",
+		Detail: fmt.Sprintf("method %s is not allowed", method),
+	}
+}
+
+// ErrUnsupportedAction returns the error to respond with when the server
+// does not support creating or updating resources, or updating
+// relationships, at the requested path.
+func ErrUnsupportedAction(action Action) Error {
+	return Error{
+		Status: http.StatusForbidden,
+		Code:   CodeUnsupportedAction,
+		Title:  "Unsupported Action",
+		Detail: fmt.Sprintf("%s is not supported", action),
+	}
+}
+
+// ErrInternal returns the error to respond with when handling a request
+// failed unexpectedly. id identifies the occurrence so it can be found in
+// the server's logs without exposing details to the client.
+func ErrInternal(id string) Error {
+	return Error{
+		ID:     id,
+		Status: http.StatusInternalServerError,
+		Code:   CodeInternal,
+		Title:  "Internal Server Error",
+		Detail: "the server encountered an unexpected error",
+	}
+}
@@ -1,7 +1,11 @@
 package jsonapi_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
 	"testing"
 
 	"github.com/crhntr/jsonapi"
@@ -88,3 +92,140 @@ func TestErrorsPolicy(t *testing.T) {
 		}
 	})
 }
+
+func TestError_Source(t *testing.T) {
+	t.Run("when it is encoded", func(t *testing.T) {
+		for _, tt := range []struct {
+			error    jsonapi.Error
+			expected string
+		}{
+			{jsonapi.Error{Source: jsonapi.AttributeSource("title")}, `{"source":{"pointer":"/data/attributes/title"}}`},
+			{jsonapi.Error{Source: jsonapi.RelationshipSource("author")}, `{"source":{"pointer":"/data/relationships/author"}}`},
+			{jsonapi.Error{Source: jsonapi.PointerSource("/data")}, `{"source":{"pointer":"/data"}}`},
+			{jsonapi.Error{Source: jsonapi.ParameterSource("sort")}, `{"source":{"parameter":"sort"}}`},
+			{jsonapi.Error{Source: jsonapi.HeaderSource("Accept")}, `{"source":{"header":"Accept"}}`},
+		} {
+			buf, err := json.Marshal(tt.error)
+			mustNotErr(t, err)
+			if string(buf) != tt.expected {
+				t.Error("it should encode the source object")
+				t.Log(string(buf))
+			}
+		}
+	})
+
+	t.Run("when it is decoded", func(t *testing.T) {
+		for _, tt := range []struct {
+			payload  string
+			expected *jsonapi.ErrorSource
+		}{
+			{`{"status": "422", "source": {"pointer": "/data/attributes/title"}}`, &jsonapi.ErrorSource{Pointer: "/data/attributes/title"}},
+			{`{"source": {"parameter": "sort", "header": "X"}}`, &jsonapi.ErrorSource{Parameter: "sort", Header: "X"}},
+			{`{"pointer": "/data/attributes/title"}`, &jsonapi.ErrorSource{Pointer: "/data/attributes/title"}},
+			{`{"parameter": "include"}`, &jsonapi.ErrorSource{Parameter: "include"}},
+			{`{"source": "/data"}`, &jsonapi.ErrorSource{Pointer: "/data"}},
+			{`{"source": "unknown"}`, nil},
+			{`{"detail": "no source"}`, nil},
+		} {
+			var error jsonapi.Error
+			mustNotErr(t, json.Unmarshal([]byte(tt.payload), &error))
+			if !reflect.DeepEqual(error.Source, tt.expected) {
+				t.Error("it should decode the source")
+				t.Log(tt.payload)
+				t.Log(error.Source)
+			}
+		}
+	})
+
+	t.Run("when other members are decoded", func(t *testing.T) {
+		var error jsonapi.Error
+		mustNotErr(t, json.Unmarshal([]byte(`{"status": "409", "code": "conflict", "meta": {"a": "b"}}`), &error))
+		if error.Status != http.StatusConflict || error.Code != "conflict" || error.Meta["a"] != "b" {
+			t.Error("it should decode the other members")
+			t.Log(error)
+		}
+	})
+}
+
+func TestErrorsPolicy_Combinations(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		statuses []int
+		expected int
+	}{
+		{"no errors", nil, http.StatusOK},
+		{"a lone 400", []int{400}, http.StatusBadRequest},
+		{"a lone 404", []int{404}, http.StatusNotFound},
+		{"a lone 500", []int{500}, http.StatusInternalServerError},
+		{"a lone 503", []int{503}, http.StatusServiceUnavailable},
+		{"a missing status", []int{0}, http.StatusInternalServerError},
+		{"a status that is not an error", []int{200}, http.StatusInternalServerError},
+		{"repeated 422s", []int{422, 422, 422}, http.StatusUnprocessableEntity},
+		{"repeated 503s", []int{503, 503}, http.StatusServiceUnavailable},
+		{"distinct 4XXs", []int{403, 404}, http.StatusBadRequest},
+		{"distinct 4XXs including 400", []int{400, 422}, http.StatusBadRequest},
+		{"distinct 5XXs", []int{502, 503}, http.StatusInternalServerError},
+		{"distinct 5XXs including 500", []int{500, 503}, http.StatusInternalServerError},
+		{"a 4XX and a 5XX", []int{404, 503}, http.StatusInternalServerError},
+		{"a 5XX and repeated 4XXs", []int{409, 409, 500}, http.StatusInternalServerError},
+		{"a 4XX and a missing status", []int{404, 0}, http.StatusInternalServerError},
+		{"a 500 and a missing status", []int{500, 0}, http.StatusInternalServerError},
+	} {
+		t.Run("when "+tt.name, func(t *testing.T) {
+			var errors []jsonapi.Error
+			for _, status := range tt.statuses {
+				errors = append(errors, jsonapi.Error{Status: status})
+			}
+			if status := jsonapi.ErrorsPolicy(errors); status != tt.expected {
+				t.Errorf("it should return %d", tt.expected)
+				t.Log(status)
+			}
+		})
+	}
+}
+
+func TestErrorCatalog(t *testing.T) {
+	for _, tt := range []struct {
+		error              jsonapi.Error
+		status             int
+		code               string
+		pointer, parameter string
+	}{
+		{jsonapi.ErrTypeMismatch("people"), http.StatusConflict, jsonapi.CodeTypeMismatch, "/data/type", ""},
+		{jsonapi.ErrClientIDForbidden(), http.StatusForbidden, jsonapi.CodeClientIDForbidden, "/data/id", ""},
+		{jsonapi.ErrIDConflict("1"), http.StatusConflict, jsonapi.CodeIDConflict, "/data/id", ""},
+		{jsonapi.ErrNotFound("articles", "1"), http.StatusNotFound, jsonapi.CodeNotFound, "", ""},
+		{jsonapi.ErrInvalidAttribute("/data/attributes/due", "must be a date"), http.StatusUnprocessableEntity, jsonapi.CodeInvalidAttribute, "/data/attributes/due", ""},
+		{jsonapi.ErrUnsupportedInclude("comments.author"), http.StatusBadRequest, jsonapi.CodeUnsupportedInclude, "", "include"},
+		{jsonapi.ErrUnsupportedSort("created"), http.StatusBadRequest, jsonapi.CodeUnsupportedSort, "", "sort"},
+	} {
+		t.Run("when "+tt.code+" is constructed", func(t *testing.T) {
+			if tt.error.Status != tt.status || tt.error.Code != tt.code || tt.error.Title == "" || tt.error.Detail == "" {
+				t.Error("it should set the status, code, title and detail")
+				t.Log(tt.error)
+			}
+
+			var source jsonapi.ErrorSource
+			if tt.error.Source != nil {
+				source = *tt.error.Source
+			}
+			if source.Pointer != tt.pointer || source.Parameter != tt.parameter {
+				t.Error("it should set the source")
+				t.Log(source)
+			}
+
+			wrapped := fmt.Errorf("handling request: %w", tt.error)
+			if !errors.Is(wrapped, jsonapi.Error{Code: tt.code}) {
+				t.Error("it should be recognized with errors.Is when wrapped")
+			}
+			if errors.Is(wrapped, jsonapi.Error{Code: "other"}) || errors.Is(wrapped, jsonapi.Error{}) {
+				t.Error("it should not match other codes")
+			}
+
+			var apiErr jsonapi.Error
+			if !errors.As(wrapped, &apiErr) || apiErr.Code != tt.code {
+				t.Error("it should be extracted with errors.As when wrapped")
+			}
+		})
+	}
+}
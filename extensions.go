@@ -0,0 +1,73 @@
+package jsonapi
+
+import (
+	"mime"
+	"strings"
+)
+
+// MediaTypeParams holds the extensions and profiles, identified by URI, of
+// the JSON:API media type. They are negotiated by ServeMux for each request
+// and may be retrieved by handlers using Negotiated.
+type MediaTypeParams struct {
+	Ext     []string
+	Profile []string
+}
+
+// HasExt reports whether the extension identified by uri is applied.
+func (params MediaTypeParams) HasExt(uri string) bool {
+	return containsString(params.Ext, uri)
+}
+
+// HasProfile reports whether the profile identified by uri is applied.
+func (params MediaTypeParams) HasProfile(uri string) bool {
+	return containsString(params.Profile, uri)
+}
+
+// ContentType returns the JSON:API media type with the ext and profile
+// parameters set when they are not empty.
+func (params MediaTypeParams) ContentType() string {
+	values := make(map[string]string)
+	if len(params.Ext) != 0 {
+		values["ext"] = strings.Join(params.Ext, " ")
+	}
+	if len(params.Profile) != 0 {
+		values["profile"] = strings.Join(params.Profile, " ")
+	}
+	return mime.FormatMediaType(ContentType, values)
+}
+
+// RegisterExtension declares that the mux supports the extension identified
+// by uri. Requests using extensions that are not registered are rejected.
+func (mux *ServeMux) RegisterExtension(uri string) {
+	if !containsString(mux.extensions, uri) {
+		mux.extensions = append(mux.extensions, uri)
+	}
+}
+
+// RegisterProfile declares that the mux supports the profile identified by
+// uri. Profiles that are not registered are ignored when requested.
+func (mux *ServeMux) RegisterProfile(uri string) {
+	if !containsString(mux.profiles, uri) {
+		mux.profiles = append(mux.profiles, uri)
+	}
+}
+
+// supportedProfiles returns the registered profiles among uris.
+func (mux ServeMux) supportedProfiles(uris []string) []string {
+	var profiles []string
+	for _, uri := range uris {
+		if containsString(mux.profiles, uri) {
+			profiles = append(profiles, uri)
+		}
+	}
+	return profiles
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FullLinkageMode configures how a TopLevelDocument handles included
+// resources that are not reachable through relationships from the primary
+// data.
+// https://jsonapi.org/format/#document-compound-documents
+type FullLinkageMode int
+
+const (
+	// FullLinkageIgnore encodes included resources without checking linkage.
+	FullLinkageIgnore FullLinkageMode = iota
+
+	// FullLinkagePrune removes unreachable included resources when encoding.
+	FullLinkagePrune
+
+	// FullLinkageReport encodes an errors document, with a 500 error listing
+	// the unreachable included resources, in place of the primary data. It is
+	// intended to catch mistakes while developing handlers.
+	FullLinkageReport
+)
+
+// SetFullLinkage sets how the document checks linkage of included resources
+// when it is encoded.
+func (doc *TopLevelDocument) SetFullLinkage(mode FullLinkageMode) {
+	doc.fullLinkage = mode
+}
+
+func (resource Resource) identity() Identity {
+	return Identity{ID: resource.ID, Type: resource.Type}
+}
+
+func (doc TopLevelDocument) primaryResources() Resources {
+	switch data := doc.Data.(type) {
+	case *Resource:
+		if data != nil {
+			return Resources{*data}
+		}
+	case Resources:
+		return data
+	}
+	return nil
+}
+
+func (doc TopLevelDocument) isPrimary(identity Identity) bool {
+	for _, resource := range doc.primaryResources() {
+		if resource.identity() == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneIncluded removes included resources not reachable from the primary
+// data. Linkage is checked before sparse fieldsets are applied as the spec
+// permits fieldsets to exclude relationships carrying linkage. It must only
+// be called on a copy of the document as in MarshalJSON.
+func (doc *TopLevelDocument) pruneIncluded() {
+	if linked, orphaned := doc.linkage(); len(orphaned) != 0 {
+		doc.Included = linked
+	}
+}
+
+// reportFullLinkage appends an error listing the included resources not
+// reachable from the primary data, and removes the included resources, when
+// the document reports full linkage and does not already have errors.
+func (doc *TopLevelDocument) reportFullLinkage() {
+	if doc.fullLinkage != FullLinkageReport || len(doc.Errors) != 0 {
+		return
+	}
+	_, orphaned := doc.linkage()
+	if len(orphaned) == 0 {
+		return
+	}
+	doc.Included = nil
+	doc.Errors = append(doc.Errors, Error{
+		Status: http.StatusInternalServerError,
+		Code:   CodeInternal,
+		Title:  "Internal Server Error",
+		Detail: fmt.Sprintf("included resources are not linked from primary data: %s", strings.Join(orphaned, ", ")),
+	})
+}
+
+// linkage splits the included resources into those reachable from the
+// primary data and descriptions of those that are not.
+func (doc TopLevelDocument) linkage() (linked Resources, orphaned []string) {
+	if len(doc.Included) == 0 {
+		return nil, nil
+	}
+
+	included := make(map[Identity]Resource, len(doc.Included))
+	for _, resource := range doc.Included {
+		included[resource.identity()] = resource
+	}
+
+	reached := make(map[Identity]bool)
+	queue := doc.primaryResources()
+	for len(queue) > 0 {
+		resource := queue[0]
+		queue = queue[1:]
+		for _, rel := range resource.Relationships {
+			linked := rel.Data.ToMany
+			if !rel.Data.IsToMany() && rel.Data.ToOne != (Identity{}) {
+				linked = []Identity{rel.Data.ToOne}
+			}
+			for _, identity := range linked {
+				if reached[identity] {
+					continue
+				}
+				reached[identity] = true
+				if next, ok := included[identity]; ok {
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	for _, resource := range doc.Included {
+		if reached[resource.identity()] {
+			linked = append(linked, resource)
+			continue
+		}
+		orphaned = append(orphaned, fmt.Sprintf("%s %q", resource.Type, resource.ID))
+	}
+	return linked, orphaned
+}
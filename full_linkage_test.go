@@ -0,0 +1,109 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+func TestTopLevelDocument_SetFullLinkage(t *testing.T) {
+	newDoc := func(mode jsonapi.FullLinkageMode) *jsonapi.TopLevelDocument {
+		articleRels := jsonapi.Relationships{}
+		articleRels.SetToOne("author", "people", "9", nil)
+		authorRels := jsonapi.Relationships{}
+		authorRels.AppendToMany("employers", "companies", "3", nil)
+
+		doc := &jsonapi.TopLevelDocument{}
+		doc.SetFullLinkage(mode)
+		doc.AppendData("articles", "1", nil, articleRels, nil, nil)
+		doc.Include("companies", "3", nil, nil, nil, nil)
+		doc.Include("people", "9", nil, authorRels, nil, nil)
+		doc.Include("people", "10", nil, nil, nil, nil)
+		return doc
+	}
+
+	includedIDs := func(t *testing.T, doc *jsonapi.TopLevelDocument) []string {
+		t.Helper()
+		buf, err := json.Marshal(doc)
+		mustNotErr(t, err)
+		var decoded struct {
+			Included []jsonapi.Identity `json:"included"`
+		}
+		mustNotErr(t, json.Unmarshal(buf, &decoded))
+		var ids []string
+		for _, identity := range decoded.Included {
+			ids = append(ids, identity.ID)
+		}
+		return ids
+	}
+
+	t.Run("when linkage is ignored", func(t *testing.T) {
+		if ids := includedIDs(t, newDoc(jsonapi.FullLinkageIgnore)); len(ids) != 3 {
+			t.Error("it should encode every included resource")
+			t.Log(ids)
+		}
+	})
+
+	t.Run("when orphans are pruned", func(t *testing.T) {
+		doc := newDoc(jsonapi.FullLinkagePrune)
+		if ids := includedIDs(t, doc); len(ids) != 2 || ids[0] != "3" || ids[1] != "9" {
+			t.Error("it should only encode resources reachable from primary data")
+			t.Log(ids)
+		}
+		if len(doc.Included) != 3 {
+			t.Error("it should not modify the document")
+		}
+	})
+
+	t.Run("when orphans are reported", func(t *testing.T) {
+		buf, err := json.Marshal(newDoc(jsonapi.FullLinkageReport))
+		mustNotErr(t, err)
+		if string(buf) != `{"errors":[{"status":"500","code":"internal-error","title":"Internal Server Error","detail":"included resources are not linked from primary data: people \"10\""}]}` {
+			t.Error("it should encode an error listing the orphaned resources")
+			t.Log(string(buf))
+		}
+	})
+
+	t.Run("when a fieldset excludes the relationship", func(t *testing.T) {
+		doc := newDoc(jsonapi.FullLinkageReport)
+		doc.Included = doc.Included[:2]
+		doc.SetFieldsets(map[string][]string{"articles": {}})
+		if ids := includedIDs(t, doc); len(ids) != 2 {
+			t.Error("it should check linkage before applying fieldsets")
+			t.Log(ids)
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_FullLinkage(t *testing.T) {
+	req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+	mustNotErr(t, err)
+	res := httptest.NewRecorder()
+
+	mux := jsonapi.ServeMux{FullLinkage: jsonapi.FullLinkageReport}
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		res.SetData("articles", id, nil, nil, nil, nil)
+		res.Include("people", "9", nil, nil, nil, nil)
+	}))
+
+	// Run
+	mux.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Error("it should respond with internal server error")
+		t.Log(res.Code)
+		t.Log(res.Body.String())
+	}
+
+	var doc struct {
+		Errors []jsonapi.Error `json:"errors"`
+	}
+	mustNotErr(t, json.Unmarshal(res.Body.Bytes(), &doc))
+	if len(doc.Errors) != 1 || doc.Errors[0].Detail != `included resources are not linked from primary data: people "9"` {
+		t.Error("it should respond with an error listing the orphaned resources")
+		t.Log(res.Body.String())
+	}
+}
@@ -1,7 +1,10 @@
 package jsonapi
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"net/http"
 )
 
@@ -13,21 +16,122 @@ type (
 	CreateResponder interface {
 		DataSetter
 		ErrorAppender
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
+		StatusSetter
 	}
 
 	// CreateRequestData represents the request body for a creating a resource.
-	CreateRequestData struct {
-		Data struct {
-			ID            string          `json:"id,omitempty"`
-			Type          string          `json:"type"`
-			Attributes    json.RawMessage `json:"attributes,omitempty"`
-			Relationships Relationships   `json:"relationships,omitempty"`
-		} `json:"data"`
+	// Use DecodeCreateRequest to read and validate it.
+	CreateRequestData = RequestData
+
+	// ClientIDValidator checks the format of a client generated ID. If the
+	// returned error is an Error it is used as is, otherwise its message is
+	// used as the detail of an ErrClientIDForbidden.
+	ClientIDValidator func(id string) error
+
+	clientIDPolicy struct {
+		permit   bool
+		validate ClientIDValidator
 	}
 )
 
-// // UnmarshalAttributes is a convenience method to unmarshal attributes into
-// // a struct or map.
-// func (data CreateRequestData) UnmarshalAttributes(attributes interface{}) error {
-// 	return json.Unmarshal(data.Attributes, attributes)
-// }
+type clientIDContextKeyT int
+
+const clientIDContextKey = clientIDContextKeyT(0)
+
+// ClientGeneratedID retrieves the validated client generated ID from a
+// request to create a resource. If the request did not include an id, an
+// empty string is returned. Handlers should respond with ErrIDConflict when a
+// resource with the id already exists.
+func ClientGeneratedID(ctx context.Context) string {
+	id, _ := ctx.Value(clientIDContextKey).(string)
+	return id
+}
+
+func clientGeneratedIDPermitted(ctx context.Context) bool {
+	_, ok := ctx.Value(clientIDContextKey).(string)
+	return ok
+}
+
+// check reads the id of the primary data in a request to create a resource
+// and returns an error when the policy does not allow it. The request body
+// is restored so the CreateFunc can read it. Malformed documents are left
+// for the CreateFunc to reject.
+func (policy clientIDPolicy) check(req *http.Request) (*http.Request, error) {
+	if !policy.permit && req.Body == nil {
+		return req, nil
+	}
+
+	var id string
+	if req.Body != nil {
+		buf, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(buf))
+		if err != nil {
+			return req, decodingError("", err.Error())
+		}
+		id = peekDataID(buf)
+	}
+
+	if id != "" {
+		if !policy.permit {
+			return req, ErrClientIDForbidden()
+		}
+		if policy.validate != nil {
+			if err := policy.validate(id); err != nil {
+				if apiErr, ok := err.(Error); ok {
+					return req, apiErr
+				}
+				forbidden := ErrClientIDForbidden()
+				forbidden.Detail = err.Error()
+				return req, forbidden
+			}
+		}
+	}
+
+	if !policy.permit {
+		return req, nil
+	}
+	return req.WithContext(context.WithValue(req.Context(), clientIDContextKey, id)), nil
+}
+
+func peekDataID(buf []byte) string {
+	members, err := decodeObject(buf, "")
+	if err != nil {
+		return ""
+	}
+	data, err := decodeObject(members["data"], "/data")
+	if err != nil {
+		return ""
+	}
+	var id string
+	if decodeString(data, "id", "/data", &id) != nil {
+		return ""
+	}
+	return id
+}
+
+// ValidateUUID is a ClientIDValidator accepting only UUIDs in their
+// canonical textual form, for example "2cbdf2a6-5a3e-4a0a-9b7d-3f3c1c1ae0f4".
+func ValidateUUID(id string) error {
+	if len(id) != 36 {
+		return errInvalidUUID
+	}
+	for i, c := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return errInvalidUUID
+			}
+		default:
+			if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+				return errInvalidUUID
+			}
+		}
+	}
+	return nil
+}
+
+var errInvalidUUID = errors.New("client generated ids must be UUIDs")
@@ -7,8 +7,12 @@ type (
 	DeleteFunc func(res DeleteResponder, req *http.Request, id string)
 
 	// DeleteResponder exposes an to a DeleteFunc how it's response
-	// should be like.
+	// should be like. By default a successful deletion responds with
+	// 204 No Content, or 200 OK with a meta only document when meta is set.
 	DeleteResponder interface {
+		DataSetter
 		ErrorAppender
+		MetaSetter
+		StatusSetter
 	}
 )
@@ -2,6 +2,7 @@ package jsonapi
 
 import (
 	"net/http"
+	"path"
 )
 
 type (
@@ -12,13 +13,17 @@ type (
 	// endpoint.
 	FetchCollectionFunc func(res FetchCollectionResponder, req *http.Request)
 
-	// FetchRelatedFunc defines how to handle a request for a related resource.
+	// FetchRelatedFunc defines how to handle a request for a related resource
+	// or resources. SetDataCollection should be called when the relationship
+	// represents an empty to-many relationship and SetNull when it represents
+	// an empty to-one relationship.
 	FetchRelatedFunc func(res FetchRelatedResponder, req *http.Request, id, relation string)
 
 	// FetchRelationshipsFunc defines how to handle a request for the identities
 	// of a relationship and the responder provides methods to render either a
 	// to-one or to-many relationship. SetDataCollection should be called when
-	// the relationship represents an empty to-many relationship.
+	// the relationship represents an empty to-many relationship and SetNull
+	// when it represents an empty to-one relationship.
 	FetchRelationshipsFunc func(res FetchRelationshipsResponder, req *http.Request, id, relation string)
 
 	// FetchCollectionResponder represents the 'ResponseWriter' for FetchOneFunc
@@ -26,6 +31,9 @@ type (
 		DataAppender
 		ErrorAppender
 		Includer
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
 	}
 
 	// FetchOneResonder represents the 'ResponseWriter' for FetchCollectionFunc
@@ -33,13 +41,20 @@ type (
 		DataSetter
 		ErrorAppender
 		Includer
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
 	}
 
 	// FetchRelatedResponder represents the 'ResponseWriter' for FetchRelatedFunc
 	FetchRelatedResponder interface {
 		DataSetter
 		DataAppender
+		NullSetter
+		DataCollectionSetter
 		ErrorAppender
+		LinksSetter
+		MetaSetter
 	}
 
 	// FetchRelationshipsResponder represents the 'ResponseWriter' for
@@ -47,7 +62,10 @@ type (
 	FetchRelationshipsResponder interface {
 		IdentitySetter
 		IdentityAppender
+		NullSetter
 		DataCollectionSetter
+		LinksSetter
+		MetaSetter
 	}
 
 	fetchResponder interface {
@@ -56,9 +74,13 @@ type (
 		DataAppender
 		IdentitySetter
 		IdentityAppender
+		NullSetter
 		ErrorAppender
 		Includer
 		DataCollectionSetter
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
 	}
 
 	fetchHandler struct {
@@ -71,9 +93,10 @@ type (
 )
 
 func (hand fetchHandler) handle(res fetchResponder, req *http.Request) {
+	notFound := errPathNotFound(path.Join("/", Endpoint(req.Context()), req.URL.Path))
 	if req.URL.Path == "/" {
 		if hand.col == nil {
-			res.WriteHeader(http.StatusNotFound)
+			res.AppendError(notFound)
 			return
 		}
 
@@ -88,7 +111,7 @@ func (hand fetchHandler) handle(res fetchResponder, req *http.Request) {
 	id, req.URL.Path = shiftPath(req.URL.Path)
 	if req.URL.Path == "/" {
 		if hand.one == nil {
-			res.WriteHeader(http.StatusNotFound)
+			res.AppendError(notFound)
 			return
 		}
 		hand.one(res, req, id)
@@ -101,22 +124,17 @@ func (hand fetchHandler) handle(res fetchResponder, req *http.Request) {
 		rel, req.URL.Path = shiftPath(req.URL.Path)
 
 		relationshipsHand, ok := hand.relationships[rel]
-		if !ok {
-			res.WriteHeader(http.StatusNotFound)
+		if !ok || relationshipsHand == nil {
+			res.AppendError(notFound)
 			return
 		}
 		relationshipsHand(res, req, id, rel)
 		return
 	}
 
-	if hand.related == nil {
-		res.WriteHeader(http.StatusNotFound)
-		return
-	}
-
 	relatedHand, ok := hand.related[rel]
-	if !ok {
-		res.WriteHeader(http.StatusNotFound)
+	if !ok || relatedHand == nil {
+		res.AppendError(notFound)
 		return
 	}
 	relatedHand(res, req, id, rel)
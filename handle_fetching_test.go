@@ -18,6 +18,10 @@ func TestFetchHandler_handle(t *testing.T) {
 		*MockErrorAppender
 		*MockIncluder
 		*MockDataCollectionSetter
+		*MockLinksSetter
+		*MockMetaSetter
+		*MockNullSetter
+		*MockJSONAPIObjectSetter
 	}
 
 	mustNotErr := func(err error) {
@@ -62,11 +66,15 @@ func TestFetchHandler_handle(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/", nil)
 			mustNotErr(err)
-			res := Response{ResponseRecorder: httptest.NewRecorder()}
+			res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+			var appended error
+			res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 			hand.handle(res, req)
-			if res.Code != http.StatusNotFound {
+			if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 				t.Error("it should return http status not found")
+				t.Log(appended)
 			}
 		})
 	})
@@ -98,11 +106,15 @@ func TestFetchHandler_handle(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/0", nil)
 			mustNotErr(err)
-			res := Response{ResponseRecorder: httptest.NewRecorder()}
+			res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+			var appended error
+			res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 			hand.handle(res, req)
-			if res.Code != http.StatusNotFound {
+			if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 				t.Error("it should return http status not found")
+				t.Log(appended)
 			}
 		})
 	})
@@ -136,11 +148,15 @@ func TestFetchHandler_handle(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/0/rel", nil)
 			mustNotErr(err)
-			res := Response{ResponseRecorder: httptest.NewRecorder()}
+			res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+			var appended error
+			res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 			hand.handle(res, req)
-			if res.Code != http.StatusNotFound {
+			if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 				t.Error("it should return http status not found")
+				t.Log(appended)
 			}
 		})
 
@@ -153,11 +169,15 @@ func TestFetchHandler_handle(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/0/rel", nil)
 			mustNotErr(err)
-			res := Response{ResponseRecorder: httptest.NewRecorder()}
+			res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+			var appended error
+			res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 			hand.handle(res, req)
-			if res.Code != http.StatusNotFound {
+			if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 				t.Error("it should return http status not found")
+				t.Log(appended)
 			}
 		})
 	})
@@ -191,11 +211,15 @@ func TestFetchHandler_handle(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/0/relationships/rel", nil)
 			mustNotErr(err)
-			res := Response{ResponseRecorder: httptest.NewRecorder()}
+			res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+			var appended error
+			res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 			hand.handle(res, req)
-			if res.Code != http.StatusNotFound {
+			if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 				t.Error("it should return http status not found")
+				t.Log(appended)
 			}
 		})
 
@@ -208,11 +232,15 @@ func TestFetchHandler_handle(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodGet, "/0/relationships/rel", nil)
 			mustNotErr(err)
-			res := Response{ResponseRecorder: httptest.NewRecorder()}
+			res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+			var appended error
+			res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 			hand.handle(res, req)
-			if res.Code != http.StatusNotFound {
+			if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 				t.Error("it should return http status not found")
+				t.Log(appended)
 			}
 		})
 	})
@@ -1,8 +1,11 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"path"
 )
 
 type (
@@ -19,35 +22,69 @@ type (
 		req *http.Request,
 		id, relation string)
 
+	// AddToManyFunc implements how members are added to a to-many relationship.
+	// identities holds the resource identifier objects decoded from the
+	// request body. Their types are not validated; append an ErrTypeMismatch
+	// for identities of a type the relationship does not hold. If the
+	// responder is left empty the response has status 204 No Content; to
+	// respond with the full linkage call SetDataCollection and AppendIdentity
+	// for each member.
+	AddToManyFunc func(
+		res UpdateToManyResponder,
+		req *http.Request,
+		id, relation string,
+		identities []Identity)
+
+	// RemoveToManyFunc implements how members are removed from a to-many
+	// relationship. It is otherwise like an AddToManyFunc.
+	RemoveToManyFunc func(
+		res UpdateToManyResponder,
+		req *http.Request,
+		id, relation string,
+		identities []Identity)
+
 	// UpdateResponder defines what to respond to a request to create a resource.
 	UpdateResponder interface {
 		DataSetter
 		ErrorAppender
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
+		StatusSetter
 	}
 
 	// UpdateRelationshipsResponder defines what to respond to a request to create a resource.
 	UpdateRelationshipsResponder interface {
 		IdentitySetter
 		IdentityAppender
+		NullSetter
+		LinksSetter
+		MetaSetter
 
 		ErrorAppender
 	}
 
-	// UpdateRequestData should be used to unmarshal update resource request
-	// bodies.
-	UpdateRequestData struct {
-		Data struct {
-			ID            string          `json:"id,omitempty"`
-			Type          string          `json:"type"`
-			Attributes    json.RawMessage `json:"attributes,omitempty"`
-			Relationships Relationships   `json:"relationships,omitempty"`
-		} `json:"data"`
+	// UpdateToManyResponder defines what to respond to a request to add or
+	// remove members of a to-many relationship.
+	UpdateToManyResponder interface {
+		IdentityAppender
+		DataCollectionSetter
+		LinksSetter
+		MetaSetter
+
+		ErrorAppender
 	}
 
+	// UpdateRequestData should be used to unmarshal update resource request
+	// bodies. Use DecodeUpdateRequest to read and validate it.
+	UpdateRequestData = RequestData
+
 	updateHandler struct {
 		one UpdateFunc
 
 		relationships map[string]UpdateRelationshipsFunc
+		add           map[string]AddToManyFunc
+		remove        map[string]RemoveToManyFunc
 	}
 
 	updateResponder interface {
@@ -56,24 +93,31 @@ type (
 		DataSetter
 		IdentitySetter
 		IdentityAppender
+		NullSetter
 		ErrorAppender
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
+		StatusSetter
 	}
-)
 
-// // UnmarshalAttributes is a convenience method to unmarshal attributes into
-// // a struct or map.
-// func (data UpdateRequestData) UnmarshalAttributes(attributes interface{}) error {
-// 	return json.Unmarshal(data.Attributes, attributes)
-// }
+	updateToManyResponder interface {
+		http.ResponseWriter
+
+		UpdateToManyResponder
+	}
+)
 
 func (hand updateHandler) handle(res updateResponder, req *http.Request) {
+	p := path.Join("/", Endpoint(req.Context()), req.URL.Path)
+
 	var (
 		id, rel string
 	)
 	id, req.URL.Path = shiftPath(req.URL.Path)
 	if req.URL.Path == "/" {
 		if hand.one == nil {
-			res.WriteHeader(http.StatusForbidden)
+			res.AppendError(ErrUnsupportedAction(ActionUpdate))
 			return
 		}
 		hand.one(res, req, id)
@@ -83,17 +127,82 @@ func (hand updateHandler) handle(res updateResponder, req *http.Request) {
 	rel, req.URL.Path = shiftPath(req.URL.Path)
 
 	if rel != "relationships" {
-		res.WriteHeader(http.StatusBadRequest)
+		res.AppendError(Error{Status: http.StatusBadRequest, Detail: fmt.Sprintf("%s is not a resource or relationships path", p)})
 		return
 	}
 
 	rel, req.URL.Path = shiftPath(req.URL.Path)
 
 	relationshipsHand, ok := hand.relationships[rel]
-	if !ok {
-		res.WriteHeader(http.StatusNotFound)
+	if !ok || relationshipsHand == nil {
+		res.AppendError(errPathNotFound(p))
 		return
 	}
 	relationshipsHand(res, req, id, rel)
-	return
+}
+
+func (hand updateHandler) handleAdd(res updateToManyResponder, req *http.Request) {
+	id, rel := shiftRelationshipsPath(req)
+	fn := hand.add[rel]
+	identities, err := decodeToManyRequestBody(req)
+	if err != nil {
+		res.AppendError(err)
+		return
+	}
+	fn(res, req, id, rel, identities)
+}
+
+func (hand updateHandler) handleRemove(res updateToManyResponder, req *http.Request) {
+	id, rel := shiftRelationshipsPath(req)
+	fn := hand.remove[rel]
+	identities, err := decodeToManyRequestBody(req)
+	if err != nil {
+		res.AppendError(err)
+		return
+	}
+	fn(res, req, id, rel, identities)
+}
+
+func shiftRelationshipsPath(req *http.Request) (id, rel string) {
+	id, req.URL.Path = shiftPath(req.URL.Path)
+	_, req.URL.Path = shiftPath(req.URL.Path)
+	rel, req.URL.Path = shiftPath(req.URL.Path)
+	return id, rel
+}
+
+// decodeToManyRequestBody decodes the array of resource identifier objects
+// from the data member of a request to add to or remove from a to-many
+// relationship.
+func decodeToManyRequestBody(req *http.Request) ([]Identity, error) {
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if req.Body == nil {
+		return nil, Error{Status: http.StatusBadRequest, Detail: "request body is missing"}
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, Error{Status: http.StatusBadRequest, Detail: err.Error()}
+	}
+	data := bytes.TrimSpace(body.Data)
+	if len(data) == 0 || data[0] != '[' {
+		return nil, Error{
+			Status: http.StatusBadRequest,
+			Detail: "data must be an array of resource identifier objects",
+			Source: PointerSource("/data"),
+		}
+	}
+	var identities []Identity
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, Error{Status: http.StatusBadRequest, Detail: err.Error(), Source: PointerSource("/data")}
+	}
+	for i, identity := range identities {
+		if identity.ID == "" || identity.Type == "" {
+			return nil, Error{
+				Status: http.StatusBadRequest,
+				Detail: "resource identifier objects must have an id and type",
+				Source: PointerSource(fmt.Sprintf("/data/%d", i)),
+			}
+		}
+	}
+	return identities, nil
 }
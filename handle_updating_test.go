@@ -15,6 +15,11 @@ func TestUpdateHandler_handle(t *testing.T) {
 		*MockIdentitySetter
 		*MockIdentityAppender
 		*MockErrorAppender
+		*MockLinksSetter
+		*MockMetaSetter
+		*MockStatusSetter
+		*MockNullSetter
+		*MockJSONAPIObjectSetter
 	}
 
 	mustNotErr := func(err error) {
@@ -53,11 +58,15 @@ func TestUpdateHandler_handle(t *testing.T) {
 
 		req, err := http.NewRequest(http.MethodGet, "/", nil)
 		mustNotErr(err)
-		res := Response{ResponseRecorder: httptest.NewRecorder()}
+		res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+		var appended error
+		res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 		hand.handle(res, req)
-		if res.Code != http.StatusForbidden {
+		if e, ok := appended.(Error); !ok || e.Status != http.StatusForbidden {
 			t.Error("it should respond with forbidden status code")
+			t.Log(appended)
 		}
 	})
 
@@ -91,11 +100,15 @@ func TestUpdateHandler_handle(t *testing.T) {
 
 		req, err := http.NewRequest(http.MethodGet, "/some-id/relationships/other", nil)
 		mustNotErr(err)
-		res := Response{ResponseRecorder: httptest.NewRecorder()}
+		res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+		var appended error
+		res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 		hand.handle(res, req)
-		if res.Code != http.StatusNotFound {
+		if e, ok := appended.(Error); !ok || e.Status != http.StatusNotFound {
 			t.Error("it should respond with not found status code")
+			t.Log(appended)
 		}
 	})
 
@@ -107,11 +120,15 @@ func TestUpdateHandler_handle(t *testing.T) {
 
 		req, err := http.NewRequest(http.MethodGet, "/some-id/something-else/other", nil)
 		mustNotErr(err)
-		res := Response{ResponseRecorder: httptest.NewRecorder()}
+		res := Response{ResponseRecorder: httptest.NewRecorder(), MockErrorAppender: NewMockErrorAppender(ctrl)}
+
+		var appended error
+		res.MockErrorAppender.EXPECT().AppendError(gomock.Any()).Do(func(err error) { appended = err })
 
 		hand.handle(res, req)
-		if res.Code != http.StatusBadRequest {
+		if e, ok := appended.(Error); !ok || e.Status != http.StatusBadRequest {
 			t.Error("it should respond with bad request status code")
+			t.Log(appended)
 		}
 	})
 }
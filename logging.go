@@ -0,0 +1,118 @@
+package jsonapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type requestLogContextKeyT int
+
+const requestLogContextKey = requestLogContextKeyT(0)
+
+// requestLog records what the mux's Logger reports about a request.
+type requestLog struct {
+	http.ResponseWriter
+
+	ctx    context.Context
+	route  Route
+	status int
+	errors []Error
+}
+
+func (entry *requestLog) WriteHeader(status int) {
+	if entry.status == 0 {
+		entry.status = status
+	}
+	entry.ResponseWriter.WriteHeader(status)
+}
+
+func (entry *requestLog) Write(buf []byte) (int, error) {
+	if entry.status == 0 {
+		entry.status = http.StatusOK
+	}
+	return entry.ResponseWriter.Write(buf)
+}
+
+func contextWithRequestLogValue(req *http.Request, entry *requestLog) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), requestLogContextKey, entry))
+}
+
+func requestLogValue(ctx context.Context) *requestLog {
+	entry, _ := ctx.Value(requestLogContextKey).(*requestLog)
+	return entry
+}
+
+// logRoute records the route of a request when it is logged.
+func logRoute(req *http.Request, route Route) {
+	if entry := requestLogValue(req.Context()); entry != nil {
+		entry.route = route
+	}
+}
+
+// logContext records the context of the request passed to the handler so
+// values added by middleware are available to the Logger.
+func logContext(req *http.Request) {
+	if entry := requestLogValue(req.Context()); entry != nil {
+		entry.ctx = req.Context()
+	}
+}
+
+// logErrors records the errors in the response to a request when it is
+// logged.
+func logErrors(req *http.Request, errors []Error) {
+	if entry := requestLogValue(req.Context()); entry != nil {
+		entry.errors = errors
+	}
+}
+
+// logRequest reports a request to the mux's Logger. Requests with a 5XX
+// status are logged at the error level, others at the info level.
+func (mux ServeMux) logRequest(req *http.Request, entry *requestLog) {
+	status := entry.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", entry.route.Endpoint),
+	}
+	if entry.route.ID != "" {
+		attrs = append(attrs, slog.String("id", entry.route.ID))
+	}
+	if entry.route.Relation != "" {
+		attrs = append(attrs, slog.String("relation", entry.route.Relation))
+	}
+	attrs = append(attrs, slog.Int("status", status))
+	if len(entry.errors) != 0 {
+		codes := make([]string, 0, len(entry.errors))
+		for _, e := range entry.errors {
+			if e.Code != "" {
+				codes = append(codes, e.Code)
+			}
+		}
+		attrs = append(attrs, slog.Int("errors", len(entry.errors)))
+		if len(codes) != 0 {
+			attrs = append(attrs, slog.Any("error_codes", codes))
+		}
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	ctx := entry.ctx
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	mux.Logger.LogAttrs(ctx, level, "jsonapi request", attrs...)
+}
+
+// logger returns the mux's Logger or the default logger when it is nil.
+func (mux ServeMux) logger() *slog.Logger {
+	if mux.Logger != nil {
+		return mux.Logger
+	}
+	return slog.Default()
+}
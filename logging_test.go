@@ -0,0 +1,204 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+type requestIDContextKey struct{}
+
+// requestIDHandler adds the request id from the context to each record.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (handler requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id, ok := ctx.Value(requestIDContextKey{}).(string); ok {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func TestServeMux_Logger(t *testing.T) {
+	decodeRecords := func(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+		t.Helper()
+		var records []map[string]interface{}
+		dec := json.NewDecoder(buf)
+		for dec.More() {
+			var record map[string]interface{}
+			mustNotErr(t, dec.Decode(&record))
+			records = append(records, record)
+		}
+		return records
+	}
+
+	t.Run("When a request has an error", func(t *testing.T) {
+		var (
+			mux jsonapi.ServeMux
+			buf bytes.Buffer
+		)
+		mux.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+		mux.HandleFetchRelated("articles", "author", jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {
+			res.AppendError(jsonapi.ErrNotFound("people", "2"))
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1/author", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		records := decodeRecords(t, &buf)
+		if len(records) != 1 {
+			t.Fatal("it should log one record")
+		}
+		record := records[0]
+		for key, expected := range map[string]interface{}{
+			"level":       "INFO",
+			"msg":         "jsonapi request",
+			"method":      http.MethodGet,
+			"endpoint":    "articles",
+			"id":          "1",
+			"relation":    "author",
+			"status":      float64(http.StatusNotFound),
+			"error_codes": []interface{}{jsonapi.CodeNotFound},
+		} {
+			if !reflect.DeepEqual(record[key], expected) {
+				t.Errorf("it should log %s", key)
+				t.Log(record[key])
+			}
+		}
+	})
+
+	t.Run("When the response document can not be encoded", func(t *testing.T) {
+		var (
+			mux jsonapi.ServeMux
+			buf bytes.Buffer
+		)
+		mux.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			res.SetData("articles", id, map[string]interface{}{"bad": make(chan int)}, nil, nil, nil)
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		records := decodeRecords(t, &buf)
+		if len(records) != 2 {
+			t.Fatal("it should log the encoding failure and the request")
+		}
+		if records[0]["level"] != "ERROR" || records[0]["error"] == nil {
+			t.Error("it should log the encoding error")
+			t.Log(records[0])
+		}
+		if records[1]["level"] != "ERROR" || records[1]["status"] != float64(http.StatusInternalServerError) {
+			t.Error("it should log the request at the error level")
+			t.Log(records[1])
+		}
+	})
+
+	t.Run("When the endpoint is not found", func(t *testing.T) {
+		var (
+			mux jsonapi.ServeMux
+			buf bytes.Buffer
+		)
+		mux.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/missing/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		records := decodeRecords(t, &buf)
+		if len(records) != 1 {
+			t.Fatal("it should log one record")
+		}
+		if records[0]["endpoint"] != "missing" || records[0]["status"] != float64(http.StatusNotFound) {
+			t.Error("it should log the endpoint and status")
+			t.Log(records[0])
+		}
+		if records[0]["id"] != "1" {
+			t.Error("it should log the id in the path")
+			t.Log(records[0])
+		}
+	})
+
+	t.Run("When a middleware adds a request id to the context", func(t *testing.T) {
+		var (
+			mux jsonapi.ServeMux
+			buf bytes.Buffer
+		)
+		mux.Logger = slog.New(requestIDHandler{slog.NewJSONHandler(&buf, nil)})
+		mux.Use(func(next jsonapi.Handler) jsonapi.Handler {
+			return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+				ctx := context.WithValue(req.Context(), requestIDContextKey{}, "req-42")
+				next(res, req.WithContext(ctx), route)
+			}
+		})
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			res.SetData("articles", id, nil, nil, nil, nil)
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		records := decodeRecords(t, &buf)
+		if len(records) != 1 || records[0]["request_id"] != "req-42" {
+			t.Error("it should log the record with the context passed to the handler")
+			t.Log(records)
+		}
+	})
+
+	t.Run("When operations are requested", func(t *testing.T) {
+		const atomicContentType = `application/vnd.api+json; ext="https://jsonapi.org/ext/atomic"`
+		var (
+			mux jsonapi.ServeMux
+			buf bytes.Buffer
+		)
+		mux.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+		mux.HandleOperations(nil)
+		mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {}))
+
+		req, err := http.NewRequest(http.MethodPost, "/operations", strings.NewReader(`{"atomic:operations": [{"op": "remove", "ref": {"type": "articles", "id": "1"}}]}`))
+		mustNotErr(t, err)
+		req.Header.Set("Accept", atomicContentType)
+		req.Header.Set("Content-Type", atomicContentType)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		records := decodeRecords(t, &buf)
+		if len(records) != 2 {
+			t.Fatal("it should log the operation and the request")
+		}
+		if records[0]["endpoint"] != "articles" || records[0]["method"] != http.MethodDelete {
+			t.Error("it should log the operation")
+			t.Log(records[0])
+		}
+		if records[1]["endpoint"] != "operations" || records[1]["status"] != float64(http.StatusNoContent) {
+			t.Error("it should log the operations endpoint")
+			t.Log(records[1])
+		}
+	})
+}
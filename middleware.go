@@ -0,0 +1,177 @@
+package jsonapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Actions a request may be routed to.
+const (
+	ActionFetch  = Action("fetch")
+	ActionCreate = Action("create")
+	ActionUpdate = Action("update")
+	ActionDelete = Action("delete")
+)
+
+type (
+	// Action identifies the kind of handler a request is routed to. Requests
+	// modifying relationships, including adding to and removing from to-many
+	// relationships, are routed to ActionUpdate.
+	Action string
+
+	// Route describes the handler a request is routed to.
+	Route struct {
+		Action   Action
+		Endpoint string
+
+		// ID is the id of the resource in the path, if any.
+		ID string
+
+		// Relation is the name of the relationship in the path, if any.
+		// Relationships is true when the path is a relationship's self link,
+		// `/:endpoint/:id/relationships/:relation`, rather than a related
+		// resource link.
+		Relation      string
+		Relationships bool
+	}
+
+	// Responder exposes the response to middleware. It implements the
+	// responder interfaces of every handler kind.
+	Responder interface {
+		http.ResponseWriter
+
+		DataSetter
+		DataAppender
+		IdentitySetter
+		IdentityAppender
+		NullSetter
+		DataCollectionSetter
+		ErrorAppender
+		Includer
+		LinksSetter
+		MetaSetter
+		JSONAPIObjectSetter
+		StatusSetter
+	}
+
+	// Handler handles a request after it has been routed.
+	Handler func(res Responder, req *http.Request, route Route)
+
+	// Middleware wraps the Handler of a routed request. It may act on the
+	// request or response before and after calling next, or respond without
+	// calling it, for example by appending an error when a request is not
+	// authorized.
+	Middleware func(next Handler) Handler
+)
+
+// Use adds middleware that wraps the handlers of every endpoint. Middleware
+// run in the order they are added, those added with Use before those added
+// with UseFor.
+func (mux *ServeMux) Use(middleware ...Middleware) {
+	mux.middleware = append(mux.middleware, middleware...)
+}
+
+// UseFor adds middleware that wraps the handlers of endpoint. They run after
+// the middleware added with Use, in the order they are added.
+func (mux *ServeMux) UseFor(endpoint string, middleware ...Middleware) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	handler.middleware = append(handler.middleware, middleware...)
+	mux.Resources[endpoint] = handler
+}
+
+// Path returns the path of the route, without the id when the route does
+// not have one.
+func (route Route) Path() string {
+	segments := []string{"", route.Endpoint}
+	if route.ID != "" {
+		segments = append(segments, route.ID)
+	}
+	if route.Relationships {
+		segments = append(segments, "relationships")
+	}
+	if route.Relation != "" {
+		segments = append(segments, route.Relation)
+	}
+	return strings.Join(segments, "/")
+}
+
+// chain wraps handler with the middleware so the first is the outermost.
+func chain(handler Handler, middleware ...[]Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		for j := len(middleware[i]) - 1; j >= 0; j-- {
+			handler = middleware[i][j](handler)
+		}
+	}
+	return handler
+}
+
+// parseRoute parses the path following the endpoint. It returns false if p
+// does not match a route, such as a relationships path without a relation or
+// a path with segments following the relation.
+func parseRoute(endpoint, p string) (Route, bool) {
+	route := Route{Endpoint: endpoint}
+
+	route.ID, p = shiftPath(p)
+	if p == "/" {
+		return route, true
+	}
+
+	var segment string
+	segment, p = shiftPath(p)
+	if segment == "relationships" {
+		route.Relationships = true
+		route.Relation, p = shiftPath(p)
+		return route, route.Relation != "" && p == "/"
+	}
+	route.Relation = segment
+	return route, p == "/"
+}
+
+// setAction sets the action the route is handled by for method. It returns
+// false if method is not supported for the path, such as POST to a resource
+// or DELETE of a collection.
+func (route *Route) setAction(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		route.Action = ActionFetch
+	case http.MethodPost:
+		route.Action = ActionCreate
+		if route.Relationships {
+			route.Action = ActionUpdate
+		} else if route.ID != "" {
+			return false
+		}
+	case http.MethodPatch:
+		route.Action = ActionUpdate
+	case http.MethodDelete:
+		route.Action = ActionDelete
+		if route.Relationships {
+			route.Action = ActionUpdate
+		} else if route.ID == "" {
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// responder is the Responder ServeMux passes to handlers. It records whether
+// the response has been written so the document is not written after it.
+type responder struct {
+	http.ResponseWriter
+	*TopLevelDocument
+
+	written bool
+}
+
+func (res *responder) WriteHeader(status int) {
+	res.written = true
+	res.ResponseWriter.WriteHeader(status)
+}
+
+func (res *responder) Write(buf []byte) (int, error) {
+	res.written = true
+	return res.ResponseWriter.Write(buf)
+}
@@ -0,0 +1,159 @@
+package jsonapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+func TestServeMux_Use(t *testing.T) {
+	t.Run("When middleware are added globally and per endpoint", func(t *testing.T) {
+		var (
+			mux   jsonapi.ServeMux
+			calls []string
+		)
+		record := func(name string) jsonapi.Middleware {
+			return func(next jsonapi.Handler) jsonapi.Handler {
+				return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+					calls = append(calls, name+" before")
+					next(res, req, route)
+					calls = append(calls, name+" after")
+				}
+			}
+		}
+		mux.UseFor("articles", record("endpoint 1"), record("endpoint 2"))
+		mux.Use(record("global 1"))
+		mux.Use(record("global 2"))
+		mux.UseFor("people", record("other endpoint"))
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			calls = append(calls, "handler")
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		expected := []string{
+			"global 1 before", "global 2 before", "endpoint 1 before", "endpoint 2 before",
+			"handler",
+			"endpoint 2 after", "endpoint 1 after", "global 2 after", "global 1 after",
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Error("it should run global middleware before endpoint middleware in the order they were added")
+			t.Log(calls)
+		}
+	})
+
+	t.Run("When a middleware responds without calling next", func(t *testing.T) {
+		var (
+			mux    jsonapi.ServeMux
+			called bool
+		)
+		mux.Use(func(next jsonapi.Handler) jsonapi.Handler {
+			return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+				if route.Action != jsonapi.ActionFetch && req.Header.Get("Authorization") == "" {
+					res.AppendError(jsonapi.Error{Status: http.StatusUnauthorized, Detail: "authorization required"})
+					return
+				}
+				next(res, req, route)
+			}
+		})
+		mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {
+			called = true
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodDelete, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if called {
+			t.Error("it should not call the handler")
+		}
+		if res.Code != http.StatusUnauthorized {
+			t.Error("it should respond with the status of the appended error")
+			t.Log(res.Code)
+		}
+		if body := res.Body.String(); body != `{"errors":[{"status":"401","detail":"authorization required"}]}` {
+			t.Error("it should respond with the appended error")
+			t.Log(body)
+		}
+	})
+
+	t.Run("When a middleware sets members of the response", func(t *testing.T) {
+		var mux jsonapi.ServeMux
+		mux.UseFor("articles", func(next jsonapi.Handler) jsonapi.Handler {
+			return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+				next(res, req, route)
+				res.SetMeta(jsonapi.Meta{"endpoint": route.Endpoint})
+			}
+		})
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			res.SetData("articles", id, nil, nil, nil, nil)
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if body := res.Body.String(); body != `{"data":{"id":"1","type":"articles"},"meta":{"endpoint":"articles"}}` {
+			t.Error("it should include the members set by the middleware")
+			t.Log(body)
+		}
+	})
+}
+
+func TestServeMux_Use_Route(t *testing.T) {
+	var (
+		mux   jsonapi.ServeMux
+		route jsonapi.Route
+	)
+	mux.Use(func(next jsonapi.Handler) jsonapi.Handler {
+		return func(res jsonapi.Responder, req *http.Request, r jsonapi.Route) {
+			route = r
+		}
+	})
+	mux.HandleFetchOne("articles", nil)
+
+	for _, tt := range []struct {
+		method, path, body string
+		route              jsonapi.Route
+	}{
+		{http.MethodGet, "/articles", "", jsonapi.Route{Action: jsonapi.ActionFetch, Endpoint: "articles"}},
+		{http.MethodGet, "/articles/1", "", jsonapi.Route{Action: jsonapi.ActionFetch, Endpoint: "articles", ID: "1"}},
+		{http.MethodGet, "/articles/1/author", "", jsonapi.Route{Action: jsonapi.ActionFetch, Endpoint: "articles", ID: "1", Relation: "author"}},
+		{http.MethodGet, "/articles/1/relationships/author", "", jsonapi.Route{Action: jsonapi.ActionFetch, Endpoint: "articles", ID: "1", Relation: "author", Relationships: true}},
+		{http.MethodPost, "/articles", `{"data":{"type":"articles"}}`, jsonapi.Route{Action: jsonapi.ActionCreate, Endpoint: "articles"}},
+		{http.MethodPost, "/articles/1/relationships/tags", `{"data":[]}`, jsonapi.Route{Action: jsonapi.ActionUpdate, Endpoint: "articles", ID: "1", Relation: "tags", Relationships: true}},
+		{http.MethodPatch, "/articles/1", `{"data":{"type":"articles","id":"1"}}`, jsonapi.Route{Action: jsonapi.ActionUpdate, Endpoint: "articles", ID: "1"}},
+		{http.MethodDelete, "/articles/1/relationships/tags", `{"data":[]}`, jsonapi.Route{Action: jsonapi.ActionUpdate, Endpoint: "articles", ID: "1", Relation: "tags", Relationships: true}},
+		{http.MethodDelete, "/articles/1", "", jsonapi.Route{Action: jsonapi.ActionDelete, Endpoint: "articles", ID: "1"}},
+	} {
+		t.Run("When routing "+tt.method+" "+tt.path, func(t *testing.T) {
+			route = jsonapi.Route{}
+			req, err := jsonapi.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if route != tt.route {
+				t.Error("it should pass the parsed route to middleware")
+				t.Log(route)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package jsonapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mediaRange is an element of an Accept header.
+type mediaRange struct {
+	mediaType string
+	params    map[string]string
+	q         float64
+}
+
+// parseAccept parses the media ranges of an Accept header as described in
+// RFC 7231 section 5.3.2. Malformed elements are ignored.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, element := range splitHeaderList(header) {
+		if element == "*" {
+			element = "*/*"
+		}
+		mediaType, params, err := mime.ParseMediaType(element)
+		if err != nil {
+			continue
+		}
+		r := mediaRange{mediaType: mediaType, params: params, q: 1}
+		if q, found := params["q"]; found {
+			r.q, err = strconv.ParseFloat(q, 64)
+			if err != nil || r.q < 0 || r.q > 1 {
+				continue
+			}
+			delete(params, "q")
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// splitHeaderList splits a comma separated header value, ignoring commas
+// within quoted strings.
+func splitHeaderList(header string) []string {
+	var (
+		elements []string
+		quoted   bool
+		start    int
+	)
+	for i := 0; i < len(header); i++ {
+		switch c := header[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			elements = append(elements, strings.TrimSpace(header[start:i]))
+			start = i + 1
+		}
+	}
+	elements = append(elements, strings.TrimSpace(header[start:]))
+	return elements
+}
+
+// checkMediaTypeParams returns a description of why the parameters of the
+// JSON:API media type are not supported or an empty string when they are.
+// Only the ext and profile parameters are allowed. Profiles that are not
+// registered are ignored but every extension must be registered.
+func (mux ServeMux) checkMediaTypeParams(params map[string]string) string {
+	for name, value := range params {
+		switch name {
+		case "profile":
+		case "ext":
+			for _, uri := range strings.Fields(value) {
+				if !containsString(mux.extensions, uri) {
+					return fmt.Sprintf("extension %q is not supported", uri)
+				}
+			}
+		default:
+			return fmt.Sprintf("media type parameter %q is not supported", name)
+		}
+	}
+	return ""
+}
+
+// mediaTypeParams returns the extensions and the registered profiles of
+// supported media type parameters.
+func (mux ServeMux) mediaTypeParams(params map[string]string) MediaTypeParams {
+	var negotiated MediaTypeParams
+	if ext := strings.Fields(params["ext"]); len(ext) != 0 {
+		negotiated.Ext = ext
+	}
+	negotiated.Profile = mux.supportedProfiles(strings.Fields(params["profile"]))
+	return negotiated
+}
+
+// checkContentType returns an ErrUnsupportedMediaType when a request with a
+// body does not have the JSON:API media type, or when the media type has
+// parameters that are not supported. Otherwise it returns the extensions and
+// profiles applied to the request document.
+func (mux ServeMux) checkContentType(req *http.Request) (MediaTypeParams, error) {
+	header := req.Header.Get("Content-Type")
+	if header == "" {
+		if hasBody(req) {
+			return MediaTypeParams{}, ErrUnsupportedMediaType(fmt.Sprintf("Content-Type %s is required when sending a request document", ContentType))
+		}
+		return MediaTypeParams{}, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header)
+	if err != nil || mediaType != ContentType {
+		return MediaTypeParams{}, ErrUnsupportedMediaType(fmt.Sprintf("Content-Type must be %s", ContentType))
+	}
+	if detail := mux.checkMediaTypeParams(params); detail != "" {
+		return MediaTypeParams{}, ErrUnsupportedMediaType(detail)
+	}
+	return mux.mediaTypeParams(params), nil
+}
+
+// checkAccept returns an ErrNotAcceptable when the Accept header of a
+// request does not allow the JSON:API media type. The most specific matching
+// media range determines whether it is acceptable. Instances of the JSON:API
+// media type with unsupported parameters are ignored; when every instance
+// has them the request is not acceptable. A missing Accept header accepts
+// any media type.
+//
+// When an instance of the JSON:API media type is acceptable, the extensions
+// and profiles of the one with the highest quality are returned and found
+// is true.
+func (mux ServeMux) checkAccept(req *http.Request) (_ MediaTypeParams, found bool, _ error) {
+	header := req.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return MediaTypeParams{}, false, nil
+	}
+
+	var (
+		instances, supported int
+		detail               string
+		selected             mediaRange
+
+		specificity = -1
+		q           float64
+	)
+	for _, r := range parseAccept(header) {
+		var s int
+		switch r.mediaType {
+		case ContentType:
+			instances++
+			if d := mux.checkMediaTypeParams(r.params); d != "" {
+				detail = d
+				continue
+			}
+			supported++
+			s = 2
+		case "application/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity || (s == specificity && r.q > q) {
+			specificity, q, selected = s, r.q, r
+		}
+	}
+
+	if instances != 0 && supported == 0 {
+		return MediaTypeParams{}, false, ErrNotAcceptable(detail)
+	}
+	if specificity < 0 || q == 0 {
+		return MediaTypeParams{}, false, ErrNotAcceptable(fmt.Sprintf("Accept must allow %s", ContentType))
+	}
+	if specificity != 2 {
+		return MediaTypeParams{}, false, nil
+	}
+	return mux.mediaTypeParams(selected.params), true, nil
+}
+
+// negotiate checks the Content-Type and Accept headers of a request and
+// returns the extensions and profiles to apply to the response. They are
+// those of the acceptable JSON:API media type, or of the request document
+// when Accept only allows it with a wildcard.
+func (mux ServeMux) negotiate(req *http.Request) (MediaTypeParams, error) {
+	requested, err := mux.checkContentType(req)
+	if err != nil {
+		return MediaTypeParams{}, err
+	}
+	accepted, found, err := mux.checkAccept(req)
+	if err != nil {
+		return MediaTypeParams{}, err
+	}
+	if found {
+		return accepted, nil
+	}
+	return requested, nil
+}
+
+// hasBody reports whether a request has a body.
+func hasBody(req *http.Request) bool {
+	return req.ContentLength != 0 || len(req.TransferEncoding) != 0
+}
@@ -0,0 +1,236 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// FetchParams represents the query parameters the spec reserves for fetching
+// data: include, fields, sort, page and filter.
+type FetchParams struct {
+	// Include is the tree of relationship paths requested with `include`.
+	Include IncludeTree
+
+	// Fields maps a resource type to the sparse fieldset requested with
+	// `fields[type]`. A type with an empty, non nil, slice requests no fields.
+	Fields map[string][]string
+
+	// Sort holds the sort fields requested with `sort` in order of precedence.
+	Sort []SortField
+
+	// Page holds the `page[key]` parameters.
+	Page map[string]string
+
+	// Filter holds the `filter[key]` parameters.
+	Filter map[string]string
+}
+
+// IncludeTree represents the relationship paths of an include parameter.
+// For example `include=comments.author,tags` is represented as
+// {"comments": {"author": {}}, "tags": {}}.
+type IncludeTree map[string]IncludeTree
+
+// Has checks if a dot separated relationship path was requested.
+func (tree IncludeTree) Has(relationshipPath string) bool {
+	for _, name := range strings.Split(relationshipPath, ".") {
+		next, ok := tree[name]
+		if !ok {
+			return false
+		}
+		tree = next
+	}
+	return true
+}
+
+// Paths returns the sorted dot separated relationship paths in the tree.
+func (tree IncludeTree) Paths() []string {
+	var paths []string
+	for name, child := range tree {
+		paths = append(paths, name)
+		for _, p := range child.Paths() {
+			paths = append(paths, name+"."+p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// SortField represents a single field of a sort parameter.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// HasFieldset checks if a sparse fieldset was requested for a resource type.
+func (params FetchParams) HasFieldset(resourceType string) bool {
+	_, ok := params.Fields[resourceType]
+	return ok
+}
+
+// ParseFetchParams parses the include, fields, sort, page and filter query
+// parameters. Malformed or repeated parameters, and other parameters named
+// only with the characters a-z, result in an Error with status 400 and a
+// source parameter naming the offending query parameter.
+func ParseFetchParams(query url.Values) (FetchParams, error) {
+	var params FetchParams
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := strings.Join(query[key], ",")
+
+		family, member, hasMember, err := splitFamilyParam(key)
+		if err != nil {
+			return params, err
+		}
+
+		switch family {
+		case "include", "sort", "fields", "page", "filter":
+			if len(query[key]) > 1 {
+				return params, paramError(key, fmt.Sprintf("%s must not be repeated", key))
+			}
+		}
+
+		switch family {
+		case "include":
+			if hasMember {
+				return params, paramError(key, "include does not accept a member name")
+			}
+			if params.Include, err = parseInclude(key, value); err != nil {
+				return params, err
+			}
+		case "sort":
+			if hasMember {
+				return params, paramError(key, "sort does not accept a member name")
+			}
+			if params.Sort, err = parseSort(key, value); err != nil {
+				return params, err
+			}
+		case "fields":
+			if !hasMember {
+				return params, paramError(key, "fields must specify a resource type like fields[type]")
+			}
+			if err := ValidateMemberName(member); err != nil {
+				return params, paramError(key, err.Error())
+			}
+			fields, err := parseFields(key, value)
+			if err != nil {
+				return params, err
+			}
+			if params.Fields == nil {
+				params.Fields = make(map[string][]string)
+			}
+			params.Fields[member] = fields
+		case "page":
+			if !hasMember {
+				return params, paramError(key, "page must specify a member like page[number]")
+			}
+			if params.Page == nil {
+				params.Page = make(map[string]string)
+			}
+			params.Page[member] = value
+		case "filter":
+			if !hasMember {
+				return params, paramError(key, "filter must specify a member like filter[name]")
+			}
+			if params.Filter == nil {
+				params.Filter = make(map[string]string)
+			}
+			params.Filter[member] = value
+		default:
+			if isReservedParamName(family) {
+				return params, paramError(key, fmt.Sprintf("%q is not a supported query parameter", family))
+			}
+		}
+	}
+
+	return params, nil
+}
+
+// isReservedParamName reports whether name is made only of the characters
+// a-z, which the specification reserves for query parameters it defines.
+// https://jsonapi.org/format/#query-parameters-custom
+func isReservedParamName(name string) bool {
+	for _, r := range name {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// splitFamilyParam splits a parameter like `fields[articles]` into its family
+// "fields" and member "articles".
+func splitFamilyParam(key string) (family, member string, hasMember bool, err error) {
+	open := strings.Index(key, "[")
+	if open < 0 {
+		return key, "", false, nil
+	}
+	if !strings.HasSuffix(key, "]") || open+1 == len(key)-1 {
+		return key[:open], "", false, paramError(key, fmt.Sprintf("%q is not a valid parameter name", key))
+	}
+	return key[:open], key[open+1 : len(key)-1], true, nil
+}
+
+func parseInclude(param, value string) (IncludeTree, error) {
+	tree := make(IncludeTree)
+	for _, relationshipPath := range strings.Split(value, ",") {
+		node := tree
+		for _, name := range strings.Split(relationshipPath, ".") {
+			if err := ValidateMemberName(name); err != nil {
+				return nil, paramError(param, fmt.Sprintf("%q is not a valid relationship path: %s", relationshipPath, err))
+			}
+			next, ok := node[name]
+			if !ok {
+				next = make(IncludeTree)
+				node[name] = next
+			}
+			node = next
+		}
+	}
+	return tree, nil
+}
+
+func parseFields(param, value string) ([]string, error) {
+	fields := []string{}
+	if value == "" {
+		return fields, nil
+	}
+	for _, field := range strings.Split(value, ",") {
+		if err := ValidateMemberName(field); err != nil {
+			return nil, paramError(param, fmt.Sprintf("%q is not a valid field name: %s", field, err))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseSort(param, value string) ([]SortField, error) {
+	var fields []SortField
+	for _, field := range strings.Split(value, ",") {
+		var sortField SortField
+		if strings.HasPrefix(field, "-") {
+			sortField.Descending = true
+			field = field[1:]
+		}
+		for _, name := range strings.Split(field, ".") {
+			if err := ValidateMemberName(name); err != nil {
+				return nil, paramError(param, fmt.Sprintf("%q is not a valid sort field: %s", field, err))
+			}
+		}
+		sortField.Field = field
+		fields = append(fields, sortField)
+	}
+	return fields, nil
+}
+
+func paramError(param, detail string) error {
+	return Error{Status: http.StatusBadRequest, Detail: detail, Source: ParameterSource(param)}
+}
@@ -0,0 +1,153 @@
+package jsonapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+func TestParseFetchParams(t *testing.T) {
+	t.Run("when all parameters are set", func(t *testing.T) {
+		query, err := url.ParseQuery("include=author,comments.author&fields[articles]=title,body&fields[people]=&sort=-created,title&page[number]=2&page[size]=10&filter[author]=9")
+		mustNotErr(t, err)
+
+		params, err := jsonapi.ParseFetchParams(query)
+		mustNotErr(t, err)
+
+		if paths := params.Include.Paths(); !reflect.DeepEqual(paths, []string{"author", "comments", "comments.author"}) {
+			t.Error("it should parse the include tree")
+			t.Log(paths)
+		}
+		if !params.Include.Has("comments.author") || params.Include.Has("comments.article") {
+			t.Error("it should check relationship paths")
+		}
+		if !reflect.DeepEqual(params.Fields, map[string][]string{"articles": {"title", "body"}, "people": {}}) {
+			t.Error("it should parse the sparse fieldsets")
+			t.Log(params.Fields)
+		}
+		if !params.HasFieldset("people") || params.HasFieldset("comments") {
+			t.Error("it should report which types have sparse fieldsets")
+		}
+		if !reflect.DeepEqual(params.Sort, []jsonapi.SortField{{Field: "created", Descending: true}, {Field: "title"}}) {
+			t.Error("it should parse the sort fields")
+			t.Log(params.Sort)
+		}
+		if !reflect.DeepEqual(params.Page, map[string]string{"number": "2", "size": "10"}) {
+			t.Error("it should parse the page parameters")
+			t.Log(params.Page)
+		}
+		if !reflect.DeepEqual(params.Filter, map[string]string{"author": "9"}) {
+			t.Error("it should parse the filter parameters")
+			t.Log(params.Filter)
+		}
+	})
+
+	t.Run("when no parameters are set", func(t *testing.T) {
+		params, err := jsonapi.ParseFetchParams(nil)
+		mustNotErr(t, err)
+		if !reflect.DeepEqual(params, jsonapi.FetchParams{}) {
+			t.Error("it should return zero params")
+			t.Log(params)
+		}
+	})
+
+	t.Run("when implementation specific parameters are set", func(t *testing.T) {
+		query, err := url.ParseQuery("camelCase=1&snake_case=2&x1=3")
+		mustNotErr(t, err)
+
+		if _, err := jsonapi.ParseFetchParams(query); err != nil {
+			t.Error("it should accept parameters with a non a-z character")
+			t.Log(err)
+		}
+	})
+
+	t.Run("when parameters are malformed", func(t *testing.T) {
+		for _, tt := range []struct {
+			query, parameter string
+		}{
+			{"include=author,,comments", "include"},
+			{"include=comments..author", "include"},
+			{"include[a]=b", "include[a]"},
+			{"fields=title", "fields"},
+			{"fields[]=title", "fields[]"},
+			{"fields[articles]=title,", "fields[articles]"},
+			{"fields[articles=title", "fields[articles"},
+			{"sort=-", "sort"},
+			{"sort=title,,body", "sort"},
+			{"page=2", "page"},
+			{"filter=x", "filter"},
+			{"foo=bar", "foo"},
+			{"foo[bar]=baz", "foo[bar]"},
+			{"include=author&include=comments", "include"},
+			{"sort=title&sort=-body", "sort"},
+			{"page[size]=1&page[size]=2", "page[size]"},
+			{"filter[author]=a&filter[author]=b", "filter[author]"},
+		} {
+			query, err := url.ParseQuery(tt.query)
+			mustNotErr(t, err)
+
+			_, err = jsonapi.ParseFetchParams(query)
+			paramErr, ok := err.(jsonapi.Error)
+			if !ok {
+				t.Error("it should return a jsonapi.Error")
+				t.Log(tt.query, err)
+				continue
+			}
+			if paramErr.Status != http.StatusBadRequest || paramErr.Source == nil || paramErr.Source.Parameter != tt.parameter {
+				t.Error("it should return a bad request error with the parameter")
+				t.Log(tt.query, paramErr)
+			}
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_FetchParams(t *testing.T) {
+	t.Run("When a handler reads the params", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles?include=author&sort=-title", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var (
+			mux    jsonapi.ServeMux
+			params jsonapi.FetchParams
+		)
+		mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {
+			params = jsonapi.Params(req.Context())
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if !params.Include.Has("author") || len(params.Sort) != 1 || !params.Sort[0].Descending {
+			t.Error("it should pass the parsed params in the request context")
+			t.Log(params)
+		}
+	})
+
+	t.Run("When the params are malformed", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles?sort=,", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {
+			t.Error("it should not call the handler")
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusBadRequest {
+			t.Error("it should respond with status bad request")
+			t.Log(res.Code)
+		}
+		if body := res.Body.String(); body != `{"errors":[{"status":"400","detail":"\"\" is not a valid sort field: a valid member name must have at least one character","source":{"parameter":"sort"}}]}` {
+			t.Error("it should respond with an error document")
+			t.Log(body)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package jsonapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicReporter receives the value and stack of a panic recovered while
+// handling req. errorID is the id of the error in the response so reports
+// can be matched with what the client received.
+type PanicReporter func(req *http.Request, errorID string, value interface{}, stack []byte)
+
+// callHandler calls handler and recovers from a panic by discarding anything
+// set on the response document and headers and appending an ErrInternal.
+func (mux ServeMux) callHandler(handler Handler, res *responder, req *http.Request, route Route) {
+	header := res.Header().Clone()
+	internal, panicked := mux.protect(req, func() {
+		handler(res, req, route)
+	})
+	if !panicked {
+		return
+	}
+	restoreHeader(res.Header(), header)
+	res.discard()
+	res.AppendError(internal)
+}
+
+// protect calls fn and recovers from a panic by reporting it to the mux's
+// PanicReporter. It returns the ErrInternal to respond with when fn
+// panicked. Panics with http.ErrAbortHandler are not recovered.
+func (mux ServeMux) protect(req *http.Request, fn func()) (internal Error, panicked bool) {
+	defer func() {
+		value := recover()
+		if value == nil {
+			return
+		}
+		if value == http.ErrAbortHandler {
+			panic(value)
+		}
+
+		id := newErrorID()
+		if mux.PanicReporter != nil {
+			mux.PanicReporter(req, id, value, debug.Stack())
+		}
+		internal, panicked = ErrInternal(id), true
+	}()
+	fn()
+	return internal, false
+}
+
+// restoreHeader replaces the values in header with those in snapshot.
+func restoreHeader(header, snapshot http.Header) {
+	for name := range header {
+		delete(header, name)
+	}
+	for name, values := range snapshot {
+		header[name] = values
+	}
+}
+
+// discard removes all members set on the document.
+func (doc *TopLevelDocument) discard() {
+	*doc = TopLevelDocument{redactInternalErrors: doc.redactInternalErrors}
+}
+
+// newErrorID returns a random identifier for an error occurrence.
+func newErrorID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf[:])
+}
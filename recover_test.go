@@ -0,0 +1,186 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+func TestServeMux_PanicRecovery(t *testing.T) {
+	t.Run("When a handler panics after setting data", func(t *testing.T) {
+		var (
+			mux jsonapi.ServeMux
+
+			reportedID    string
+			reportedValue interface{}
+			reportedStack []byte
+		)
+		mux.PanicReporter = func(req *http.Request, errorID string, value interface{}, stack []byte) {
+			reportedID, reportedValue, reportedStack = errorID, value, stack
+		}
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			res.SetData("articles", id, nil, nil, nil, nil)
+			res.Include("people", "9", nil, nil, nil, nil)
+			res.AppendError(jsonapi.ErrNotFound("comments", "5"))
+			panic("secret database password")
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusInternalServerError {
+			t.Error("it should respond with internal server error")
+			t.Log(res.Code)
+		}
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+			t.Error("it should respond with a JSON:API document")
+			t.Log(res.Body.String())
+		}
+		if len(doc) != 1 || doc["errors"] == nil {
+			t.Error("it should discard the data, included resources and errors set by the handler")
+			t.Log(res.Body.String())
+		}
+		var errs []jsonapi.Error
+		json.Unmarshal(doc["errors"], &errs)
+		if len(errs) != 1 || errs[0].Code != jsonapi.CodeInternal || errs[0].ID == "" {
+			t.Error("it should respond with an internal error with an id")
+			t.Log(res.Body.String())
+		}
+		if strings.Contains(res.Body.String(), "secret") {
+			t.Error("it should not expose the panic value")
+		}
+		if len(errs) == 1 && reportedID != errs[0].ID {
+			t.Error("it should report the id of the error in the response")
+			t.Log(reportedID)
+		}
+		if reportedValue != "secret database password" {
+			t.Error("it should report the panic value")
+			t.Log(reportedValue)
+		}
+		if !strings.Contains(string(reportedStack), "recover_test.go") {
+			t.Error("it should report the stack of the panic")
+		}
+	})
+
+	t.Run("When a middleware panics without a reporter", func(t *testing.T) {
+		var mux jsonapi.ServeMux
+		mux.Use(func(next jsonapi.Handler) jsonapi.Handler {
+			return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+				var m map[string]string
+				m["boom"] = "boom"
+			}
+		})
+		mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {}))
+
+		req, err := jsonapi.NewRequest(http.MethodDelete, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusInternalServerError {
+			t.Error("it should respond with internal server error")
+			t.Log(res.Code)
+		}
+	})
+
+	t.Run("When a middleware sets a header before a handler panics", func(t *testing.T) {
+		var mux jsonapi.ServeMux
+		mux.Use(func(next jsonapi.Handler) jsonapi.Handler {
+			return func(res jsonapi.Responder, req *http.Request, route jsonapi.Route) {
+				res.Header().Set("X-Session", "secret")
+				next(res, req, route)
+			}
+		})
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			panic("boom")
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusInternalServerError {
+			t.Error("it should respond with internal server error")
+			t.Log(res.Code)
+		}
+		if res.Header().Get("X-Session") != "" {
+			t.Error("it should discard the headers set before the panic")
+		}
+		if res.Header().Get("Content-Type") != jsonapi.ContentType {
+			t.Error("it should keep the headers set by the mux")
+			t.Log(res.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("When encoding the response document panics", func(t *testing.T) {
+		var (
+			mux        jsonapi.ServeMux
+			reportedID string
+		)
+		mux.PanicReporter = func(req *http.Request, errorID string, value interface{}, stack []byte) {
+			reportedID = errorID
+		}
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			res.SetData("articles", id, panickingAttributes{}, nil, nil, nil)
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusInternalServerError {
+			t.Error("it should respond with internal server error")
+			t.Log(res.Code)
+		}
+		var doc struct {
+			Errors []jsonapi.Error `json:"errors"`
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil || len(doc.Errors) != 1 || doc.Errors[0].ID == "" || doc.Errors[0].ID != reportedID {
+			t.Error("it should respond with the reported internal error")
+			t.Log(res.Body.String())
+		}
+	})
+
+	t.Run("When a handler aborts", func(t *testing.T) {
+		var mux jsonapi.ServeMux
+		mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		defer func() {
+			if recover() != http.ErrAbortHandler {
+				t.Error("it should not recover from http.ErrAbortHandler")
+			}
+		}()
+
+		// Run
+		mux.ServeHTTP(res, req)
+	})
+}
+
+type panickingAttributes struct{}
+
+func (panickingAttributes) MarshalJSON() ([]byte, error) {
+	panic("attributes can not be encoded")
+}
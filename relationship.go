@@ -10,7 +10,7 @@ import (
 type Relationship struct {
 	Data ResourceLinkage `json:"data,omitempty"`
 
-	Links Links `json:"link,omitempty"`
+	Links Links `json:"links,omitempty"`
 	Meta  Meta  `json:"meta,omitempty"`
 }
 
@@ -1,12 +1,19 @@
 package jsonapi
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 // NewRequest sets required jsonapi headers for requests to jsonapi servers.
-// It is used internally for tests and examples.
+// It is used by the client package and in tests and examples.
 func NewRequest(method string, path string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequest(method, path, body)
 	if err != nil {
@@ -16,3 +23,216 @@ func NewRequest(method string, path string, body io.Reader) (*http.Request, erro
 	req.Header.Set("Content-Type", ContentType)
 	return req, nil
 }
+
+// RequestData represents the request body for creating or updating a
+// resource.
+type RequestData struct {
+	Data struct {
+		ID            string          `json:"id,omitempty"`
+		Type          string          `json:"type"`
+		Attributes    json.RawMessage `json:"attributes,omitempty"`
+		Relationships Relationships   `json:"relationships,omitempty"`
+	} `json:"data"`
+}
+
+// RequestDecoder reads and validates request documents.
+type RequestDecoder struct {
+	// Types lists the resource types accepted as primary data. If it is
+	// empty, only the endpoint from the request context is accepted.
+	Types []string
+
+	// PermitClientGeneratedID allows requests to create a resource to include
+	// an id when decoding requests outside of a ServeMux. ServeMux forbids
+	// requests including an id before the CreateFunc is called unless the
+	// endpoint was configured with ServeMux.PermitClientGeneratedIDs, so this
+	// has no effect there.
+	PermitClientGeneratedID bool
+}
+
+// DecodeCreateRequest reads a request to create a resource using the zero
+// RequestDecoder.
+func DecodeCreateRequest(req *http.Request) (RequestData, error) {
+	return RequestDecoder{}.DecodeCreate(req)
+}
+
+// DecodeUpdateRequest reads a request to update the resource with id using
+// the zero RequestDecoder.
+func DecodeUpdateRequest(req *http.Request, id string) (RequestData, error) {
+	return RequestDecoder{}.DecodeUpdate(req, id)
+}
+
+// DecodeCreate reads a request to create a resource. The returned error is
+// an Error that may be appended to the response as is.
+func (dec RequestDecoder) DecodeCreate(req *http.Request) (RequestData, error) {
+	data, err := dec.decode(req)
+	if err != nil {
+		return data, err
+	}
+	if data.Data.ID != "" && !dec.PermitClientGeneratedID && !clientGeneratedIDPermitted(req.Context()) {
+		return data, ErrClientIDForbidden()
+	}
+	return data, nil
+}
+
+// DecodeUpdate reads a request to update the resource with id. The id in
+// the request document must match. The returned error is an Error that may
+// be appended to the response as is.
+func (dec RequestDecoder) DecodeUpdate(req *http.Request, id string) (RequestData, error) {
+	data, err := dec.decode(req)
+	if err != nil {
+		return data, err
+	}
+	if data.Data.ID == "" {
+		return data, decodingError("/data/id", "a resource object in a request to update a resource must have an id")
+	}
+	if data.Data.ID != id {
+		return data, Error{
+			Status: http.StatusConflict,
+			Detail: fmt.Sprintf("id %q does not match the id %q of the resource being updated", data.Data.ID, id),
+			Source: PointerSource("/data/id"),
+		}
+	}
+	return data, nil
+}
+
+func (dec RequestDecoder) decode(req *http.Request) (RequestData, error) {
+	var data RequestData
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != ContentType {
+		return data, ErrUnsupportedMediaType(fmt.Sprintf("Content-Type must be %s", ContentType))
+	}
+
+	if req.Body == nil {
+		return data, decodingError("", "request body is missing")
+	}
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		return data, decodingError("", err.Error())
+	}
+	if !json.Valid(buf) {
+		return data, decodingError("", "request body is not valid json")
+	}
+
+	members, err := decodeObject(buf, "")
+	if err != nil {
+		return data, err
+	}
+	dataBuf, ok := members["data"]
+	if !ok {
+		return data, decodingError("", "a request document must contain data")
+	}
+	if firstByte(dataBuf) != '{' {
+		return data, decodingError("/data", "data must be a resource object")
+	}
+	resource, err := decodeResource(dataBuf, "/data")
+	if err != nil {
+		return data, err
+	}
+
+	if !dec.acceptsType(req, resource.Type) {
+		return data, ErrTypeMismatch(resource.Type)
+	}
+
+	data.Data.ID = resource.ID
+	data.Data.Type = resource.Type
+	data.Data.Attributes, _ = resource.Attributes.(json.RawMessage)
+	data.Data.Relationships = resource.Relationships
+	return data, nil
+}
+
+func (dec RequestDecoder) acceptsType(req *http.Request, resourceType string) bool {
+	if len(dec.Types) == 0 {
+		return resourceType == Endpoint(req.Context())
+	}
+	for _, t := range dec.Types {
+		if t == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalAttributes decodes the attributes of the primary data into v.
+// When an attribute can not be decoded, the returned Error has a source
+// pointer to it like "/data/attributes/due".
+func (data RequestData) UnmarshalAttributes(v interface{}) error {
+	if len(data.Data.Attributes) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(data.Data.Attributes, v)
+	if err == nil {
+		return nil
+	}
+	return ErrInvalidAttribute(attributePointer(data.Data.Attributes, v, err), err.Error())
+}
+
+// attributePointer finds the attribute that caused err. When the error does
+// not name the field, each attribute is decoded on its own into a new value
+// of v's type to find the one that fails.
+func attributePointer(attributes json.RawMessage, v interface{}, err error) string {
+	const prefix = "/data/attributes"
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return prefix + "/" + strings.ReplaceAll(typeErr.Field, ".", "/")
+	}
+
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return prefix
+	}
+
+	var members map[string]json.RawMessage
+	if json.Unmarshal(attributes, &members) != nil {
+		return prefix
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		single, _ := json.Marshal(map[string]json.RawMessage{name: members[name]})
+		if json.Unmarshal(single, reflect.New(t.Elem()).Interface()) != nil {
+			return prefix + "/" + name
+		}
+	}
+	return prefix
+}
+
+// ToOne returns the to-one linkage of the named relationship. present is
+// false when the relationship is not in the request and a null linkage is
+// returned as a zero Identity.
+func (data RequestData) ToOne(relationship string) (identity Identity, present bool, err error) {
+	rel, present := data.Data.Relationships[relationship]
+	if !present {
+		return identity, false, nil
+	}
+	if rel.Data.IsToMany() {
+		return identity, true, Error{
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("relationship %q must be a to-one relationship", relationship),
+			Source: PointerSource("/data/relationships/" + relationship + "/data"),
+		}
+	}
+	return rel.Data.ToOne, true, nil
+}
+
+// ToMany returns the to-many linkage of the named relationship. present is
+// false when the relationship is not in the request.
+func (data RequestData) ToMany(relationship string) (identities []Identity, present bool, err error) {
+	rel, present := data.Data.Relationships[relationship]
+	if !present {
+		return nil, false, nil
+	}
+	if !rel.Data.IsToMany() {
+		return nil, true, Error{
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("relationship %q must be a to-many relationship", relationship),
+			Source: PointerSource("/data/relationships/" + relationship + "/data"),
+		}
+	}
+	return rel.Data.ToMany, true, nil
+}
@@ -1,7 +1,10 @@
 package jsonapi
 
 import (
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewRequest(t *testing.T) {
@@ -10,3 +13,156 @@ func TestNewRequest(t *testing.T) {
 		t.Error("should fail")
 	}
 }
+
+func TestRequestDecoder(t *testing.T) {
+	newRequest := func(t *testing.T, body string) *http.Request {
+		t.Helper()
+		req, err := NewRequest(http.MethodPost, "/articles", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return contextWithEndpointValue(req, "articles")
+	}
+
+	sourcePointer := func(err error) string {
+		apiErr, ok := err.(Error)
+		if !ok || apiErr.Source == nil {
+			return ""
+		}
+		return apiErr.Source.Pointer
+	}
+
+	t.Run("when a valid create request is decoded", func(t *testing.T) {
+		req := newRequest(t, `{"data": {
+			"type": "articles",
+			"attributes": {"title": "JSON:API", "due": "2019-01-01T00:00:00Z"},
+			"relationships": {
+				"author": {"data": {"type": "people", "id": "9"}},
+				"editor": {"data": null},
+				"tags": {"data": [{"type": "tags", "id": "2"}]}
+			}
+		}}`)
+
+		data, err := DecodeCreateRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var attributes struct {
+			Title string    `json:"title"`
+			Due   time.Time `json:"due"`
+		}
+		if err := data.UnmarshalAttributes(&attributes); err != nil || attributes.Title != "JSON:API" || attributes.Due.Year() != 2019 {
+			t.Error("it should decode the attributes")
+			t.Log(err, attributes)
+		}
+
+		if author, present, err := data.ToOne("author"); err != nil || !present || author != (Identity{ID: "9", Type: "people"}) {
+			t.Error("it should return the to-one linkage")
+			t.Log(author, present, err)
+		}
+		if editor, present, err := data.ToOne("editor"); err != nil || !present || editor != (Identity{}) {
+			t.Error("it should return a null to-one linkage as a zero identity")
+			t.Log(editor, present, err)
+		}
+		if _, present, _ := data.ToOne("reviewer"); present {
+			t.Error("it should report missing relationships")
+		}
+		if tags, present, err := data.ToMany("tags"); err != nil || !present || len(tags) != 1 {
+			t.Error("it should return the to-many linkage")
+			t.Log(tags, present, err)
+		}
+		if _, _, err := data.ToMany("author"); sourcePointer(err) != "/data/relationships/author/data" {
+			t.Error("it should return an error when a to-one relationship is used as a to-many")
+			t.Log(err)
+		}
+		if _, _, err := data.ToOne("tags"); sourcePointer(err) != "/data/relationships/tags/data" {
+			t.Error("it should return an error when a to-many relationship is used as a to-one")
+			t.Log(err)
+		}
+	})
+
+	t.Run("when attributes can not be decoded", func(t *testing.T) {
+		for _, tt := range []struct {
+			attributes, pointer string
+		}{
+			{`{"title": 1}`, "/data/attributes/title"},
+			{`{"title": "JSON:API", "due": "tomorrow"}`, "/data/attributes/due"},
+			{`{"author": {"name": 2}}`, "/data/attributes/author/name"},
+		} {
+			req := newRequest(t, `{"data": {"type": "articles", "attributes": `+tt.attributes+`}}`)
+			data, err := DecodeCreateRequest(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var attributes struct {
+				Title  string    `json:"title"`
+				Due    time.Time `json:"due"`
+				Author struct {
+					Name string `json:"name"`
+				} `json:"author"`
+			}
+			err = data.UnmarshalAttributes(&attributes)
+			if apiErr, ok := err.(Error); !ok || apiErr.Status != http.StatusUnprocessableEntity || sourcePointer(err) != tt.pointer {
+				t.Error("it should return an error pointing to the attribute")
+				t.Log(tt.attributes, err)
+			}
+		}
+	})
+
+	t.Run("when the request is not valid", func(t *testing.T) {
+		for _, tt := range []struct {
+			name, contentType, body string
+			status                  int
+			pointer                 string
+		}{
+			{"wrong content type", "application/json", `{"data": {"type": "articles"}}`, http.StatusUnsupportedMediaType, ""},
+			{"invalid json", ContentType, `{"data":`, http.StatusBadRequest, ""},
+			{"missing data", ContentType, `{"meta": {}}`, http.StatusBadRequest, ""},
+			{"array data", ContentType, `{"data": []}`, http.StatusBadRequest, "/data"},
+			{"missing type", ContentType, `{"data": {"attributes": {}}}`, http.StatusBadRequest, "/data/type"},
+			{"type mismatch", ContentType, `{"data": {"type": "people"}}`, http.StatusConflict, "/data/type"},
+			{"client generated id", ContentType, `{"data": {"type": "articles", "id": "1"}}`, http.StatusForbidden, "/data/id"},
+		} {
+			req := newRequest(t, tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			_, err := DecodeCreateRequest(req)
+			apiErr, ok := err.(Error)
+			if !ok || apiErr.Status != tt.status || sourcePointer(err) != tt.pointer {
+				t.Errorf("it should reject a request with %s", tt.name)
+				t.Log(err)
+			}
+		}
+	})
+
+	t.Run("when types and client ids are permitted", func(t *testing.T) {
+		req := newRequest(t, `{"data": {"type": "bug", "id": "7d6a"}}`)
+		dec := RequestDecoder{Types: []string{"bug", "feature"}, PermitClientGeneratedID: true}
+		data, err := dec.DecodeCreate(req)
+		if err != nil || data.Data.ID != "7d6a" || data.Data.Type != "bug" {
+			t.Error("it should accept the request")
+			t.Log(data, err)
+		}
+	})
+
+	t.Run("when an update request is decoded", func(t *testing.T) {
+		if _, err := DecodeUpdateRequest(newRequest(t, `{"data": {"type": "articles", "id": "1"}}`), "1"); err != nil {
+			t.Error("it should accept a matching id")
+			t.Log(err)
+		}
+
+		_, err := DecodeUpdateRequest(newRequest(t, `{"data": {"type": "articles", "id": "2"}}`), "1")
+		if apiErr, ok := err.(Error); !ok || apiErr.Status != http.StatusConflict || sourcePointer(err) != "/data/id" {
+			t.Error("it should reject a mismatched id")
+			t.Log(err)
+		}
+
+		_, err = DecodeUpdateRequest(newRequest(t, `{"data": {"type": "articles"}}`), "1")
+		if sourcePointer(err) != "/data/id" {
+			t.Error("it should reject a missing id")
+			t.Log(err)
+		}
+	})
+}
@@ -36,6 +36,40 @@ type (
 		Include(resourceType, id string, attributes interface{}, relationships Relationships, links Links, meta Meta) error
 	}
 
+	// LinksSetter represents the interface to set members of the top level
+	// `links` object such as self, related and pagination links.
+	LinksSetter interface {
+		SetLinks(links Links)
+	}
+
+	// MetaSetter represents the interface to set members of the top level
+	// `meta` object.
+	MetaSetter interface {
+		SetMeta(meta Meta)
+	}
+
+	// JSONAPIObjectSetter represents the interface to set the top level
+	// `jsonapi` object.
+	JSONAPIObjectSetter interface {
+		SetJSONAPIObject(obj JSONAPIObject)
+	}
+
+	// StatusSetter represents the interface to override the status code of a
+	// successful response. Use http.StatusNoContent to respond without a
+	// document, http.StatusAccepted with a job resource as data when the
+	// request is processed asynchronously, or http.StatusOK when the server
+	// changed a created resource in ways not specified by the request. It is
+	// ignored when errors are appended.
+	StatusSetter interface {
+		SetStatus(status int)
+	}
+
+	// NullSetter represents the interface to respond with null primary data,
+	// such as when fetching an empty to-one relationship.
+	NullSetter interface {
+		SetNull()
+	}
+
 	// DataCollectionSetter represents the interface to ensure top level document
 	//  member `data` is encoded as an empty array when encoding an empty
 	// collection. It is used interanally and is exported for mocking responses.
@@ -217,6 +217,171 @@ func (mr *MockIncluderMockRecorder) Include(resourceType, id, attributes, relati
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Include", reflect.TypeOf((*MockIncluder)(nil).Include), resourceType, id, attributes, relationships, links, meta)
 }
 
+// MockLinksSetter is a mock of LinksSetter interface
+type MockLinksSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLinksSetterMockRecorder
+}
+
+// MockLinksSetterMockRecorder is the mock recorder for MockLinksSetter
+type MockLinksSetterMockRecorder struct {
+	mock *MockLinksSetter
+}
+
+// NewMockLinksSetter creates a new mock instance
+func NewMockLinksSetter(ctrl *gomock.Controller) *MockLinksSetter {
+	mock := &MockLinksSetter{ctrl: ctrl}
+	mock.recorder = &MockLinksSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLinksSetter) EXPECT() *MockLinksSetterMockRecorder {
+	return m.recorder
+}
+
+// SetLinks mocks base method
+func (m *MockLinksSetter) SetLinks(links Links) {
+	m.ctrl.Call(m, "SetLinks", links)
+}
+
+// SetLinks indicates an expected call of SetLinks
+func (mr *MockLinksSetterMockRecorder) SetLinks(links interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLinks", reflect.TypeOf((*MockLinksSetter)(nil).SetLinks), links)
+}
+
+// MockMetaSetter is a mock of MetaSetter interface
+type MockMetaSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetaSetterMockRecorder
+}
+
+// MockMetaSetterMockRecorder is the mock recorder for MockMetaSetter
+type MockMetaSetterMockRecorder struct {
+	mock *MockMetaSetter
+}
+
+// NewMockMetaSetter creates a new mock instance
+func NewMockMetaSetter(ctrl *gomock.Controller) *MockMetaSetter {
+	mock := &MockMetaSetter{ctrl: ctrl}
+	mock.recorder = &MockMetaSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMetaSetter) EXPECT() *MockMetaSetterMockRecorder {
+	return m.recorder
+}
+
+// SetMeta mocks base method
+func (m *MockMetaSetter) SetMeta(meta Meta) {
+	m.ctrl.Call(m, "SetMeta", meta)
+}
+
+// SetMeta indicates an expected call of SetMeta
+func (mr *MockMetaSetterMockRecorder) SetMeta(meta interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMeta", reflect.TypeOf((*MockMetaSetter)(nil).SetMeta), meta)
+}
+
+// MockJSONAPIObjectSetter is a mock of JSONAPIObjectSetter interface
+type MockJSONAPIObjectSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockJSONAPIObjectSetterMockRecorder
+}
+
+// MockJSONAPIObjectSetterMockRecorder is the mock recorder for MockJSONAPIObjectSetter
+type MockJSONAPIObjectSetterMockRecorder struct {
+	mock *MockJSONAPIObjectSetter
+}
+
+// NewMockJSONAPIObjectSetter creates a new mock instance
+func NewMockJSONAPIObjectSetter(ctrl *gomock.Controller) *MockJSONAPIObjectSetter {
+	mock := &MockJSONAPIObjectSetter{ctrl: ctrl}
+	mock.recorder = &MockJSONAPIObjectSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockJSONAPIObjectSetter) EXPECT() *MockJSONAPIObjectSetterMockRecorder {
+	return m.recorder
+}
+
+// SetJSONAPIObject mocks base method
+func (m *MockJSONAPIObjectSetter) SetJSONAPIObject(obj JSONAPIObject) {
+	m.ctrl.Call(m, "SetJSONAPIObject", obj)
+}
+
+// SetJSONAPIObject indicates an expected call of SetJSONAPIObject
+func (mr *MockJSONAPIObjectSetterMockRecorder) SetJSONAPIObject(obj interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetJSONAPIObject", reflect.TypeOf((*MockJSONAPIObjectSetter)(nil).SetJSONAPIObject), obj)
+}
+
+// MockStatusSetter is a mock of StatusSetter interface
+type MockStatusSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatusSetterMockRecorder
+}
+
+// MockStatusSetterMockRecorder is the mock recorder for MockStatusSetter
+type MockStatusSetterMockRecorder struct {
+	mock *MockStatusSetter
+}
+
+// NewMockStatusSetter creates a new mock instance
+func NewMockStatusSetter(ctrl *gomock.Controller) *MockStatusSetter {
+	mock := &MockStatusSetter{ctrl: ctrl}
+	mock.recorder = &MockStatusSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStatusSetter) EXPECT() *MockStatusSetterMockRecorder {
+	return m.recorder
+}
+
+// SetStatus mocks base method
+func (m *MockStatusSetter) SetStatus(status int) {
+	m.ctrl.Call(m, "SetStatus", status)
+}
+
+// SetStatus indicates an expected call of SetStatus
+func (mr *MockStatusSetterMockRecorder) SetStatus(status interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockStatusSetter)(nil).SetStatus), status)
+}
+
+// MockNullSetter is a mock of NullSetter interface
+type MockNullSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockNullSetterMockRecorder
+}
+
+// MockNullSetterMockRecorder is the mock recorder for MockNullSetter
+type MockNullSetterMockRecorder struct {
+	mock *MockNullSetter
+}
+
+// NewMockNullSetter creates a new mock instance
+func NewMockNullSetter(ctrl *gomock.Controller) *MockNullSetter {
+	mock := &MockNullSetter{ctrl: ctrl}
+	mock.recorder = &MockNullSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockNullSetter) EXPECT() *MockNullSetterMockRecorder {
+	return m.recorder
+}
+
+// SetNull mocks base method
+func (m *MockNullSetter) SetNull() {
+	m.ctrl.Call(m, "SetNull")
+}
+
+// SetNull indicates an expected call of SetNull
+func (mr *MockNullSetterMockRecorder) SetNull() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNull", reflect.TypeOf((*MockNullSetter)(nil).SetNull))
+}
+
 // MockDataCollectionSetter is a mock of DataCollectionSetter interface
 type MockDataCollectionSetter struct {
 	ctrl     *gomock.Controller
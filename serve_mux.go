@@ -2,9 +2,11 @@ package jsonapi
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
 )
 
@@ -12,21 +14,74 @@ import (
 // it implements http.Handler. It's zero value is valid.
 type ServeMux struct {
 	Resources map[string]EndpointHandler
+
+	// FullLinkage sets how included resources not reachable from the primary
+	// data are handled. Use FullLinkageReport while developing to respond
+	// with an error listing the unrelated resources a handler includes.
+	FullLinkage FullLinkageMode
+
+	// ErrorsPolicy calculates the response status code when a handler appends
+	// errors. If it is nil, the package level ErrorsPolicy is used.
+	ErrorsPolicy func(errors []Error) int
+
+	// UnimplementedPolicy returns the error to respond with when no handler
+	// is registered for a request. If it is nil, the package level
+	// UnimplementedPolicy is used. Requests to paths that do not match a
+	// route, such as a relationships path without a relation, result in 404
+	// (not found) without calling it.
+	UnimplementedPolicy func(method string, route Route, allowed []string) Error
+
+	// PanicReporter is called when a handler, middleware, transaction hook or
+	// the encoding of a response document panics. The response is an errors
+	// document with an ErrInternal; the document and headers a handler or
+	// middleware set are discarded unless the response was already written.
+	PanicReporter PanicReporter
+
+	// Logger records the method, endpoint, id, relation, status and error
+	// codes of each request, at the error level for 5XX responses. Records
+	// are logged with the context of the request passed to the handler so
+	// middleware, or handlers wrapping the mux, may add request IDs.
+	// If it is nil, requests are not logged and failures to encode a response
+	// are logged with the default logger.
+	Logger *slog.Logger
+
+	// RedactInternalErrors replaces the detail of errors that are not a
+	// jsonapi Error, and do not have a 4XX status, with a generic message so
+	// internal details are not exposed to clients.
+	RedactInternalErrors bool
+
+	extensions []string
+	profiles   []string
+
+	atomic bool
+	begin  BeginFunc
+
+	middleware []Middleware
 }
 
 func (mux ServeMux) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	accept := req.Header.Get("Accept")
-	if !strings.HasPrefix(accept, ContentType) {
-		res.WriteHeader(http.StatusNotAcceptable)
-		return
+	if mux.Logger != nil {
+		entry := &requestLog{ResponseWriter: res}
+		res = entry
+		req = contextWithRequestLogValue(req, entry)
+		defer mux.logRequest(req, entry)
 	}
-	contentType := req.Header.Get("Content-Type")
-	if !strings.HasPrefix(contentType, ContentType) {
-		res.WriteHeader(http.StatusUnsupportedMediaType)
+
+	res.Header().Set("Content-Type", ContentType)
+
+	negotiated, err := mux.negotiate(req)
+	if err != nil {
+		mux.writeError(res, req, err)
 		return
 	}
+	req = contextWithMediaTypeParamsValue(req, negotiated)
+	res.Header().Set("Content-Type", negotiated.ContentType())
 
-	res.Header().Set("Content-Type", ContentType)
+	if mux.atomic && path.Clean(req.URL.Path) == "/"+operationsEndpoint {
+		logRoute(req, Route{Endpoint: operationsEndpoint})
+		mux.handleOperations(res, req, negotiated)
+		return
+	}
 
 	if req.URL.Path == "/" {
 		json.NewEncoder(res).Encode(struct{}{})
@@ -38,60 +93,318 @@ func (mux ServeMux) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 
 	req = contextWithEndpointValue(req, endpoint)
 
+	route, matched := parseRoute(endpoint, req.URL.Path)
+	ok := route.setAction(req.Method)
+	logRoute(req, route)
+
+	if !matched {
+		mux.writeError(res, req, errPathNotFound(path.Join("/", endpoint, req.URL.Path)))
+		return
+	}
 	hand, found := mux.Resources[endpoint]
 	if !found {
-		res.WriteHeader(http.StatusNotFound)
+		mux.writeError(res, req, mux.unimplemented(res, req.Method, route, nil))
 		return
 	}
+	if req.Method == http.MethodOptions {
+		allowed := hand.allowed(route)
+		if len(allowed) == 1 {
+			mux.writeError(res, req, mux.unimplemented(res, req.Method, route, allowed))
+			return
+		}
+		writeOptions(res, allowed)
+		return
+	}
+	if !ok {
+		mux.writeMethodNotAllowed(res, req, hand.allowed(route))
+		return
+	}
+	if req.Method == http.MethodHead {
+		res = bodylessResponseWriter{res}
+	}
 
-	resDoc := struct {
-		http.ResponseWriter
-		*TopLevelDocument
-	}{ResponseWriter: res, TopLevelDocument: &TopLevelDocument{}}
+	resDoc := &responder{ResponseWriter: res, TopLevelDocument: &TopLevelDocument{}}
+	resDoc.SetInternalErrorRedaction(mux.RedactInternalErrors)
+
+	params, err := ParseFetchParams(req.URL.Query())
+	if err != nil {
+		resDoc.AppendError(err)
+		mux.writeDocument(res, req, resDoc.TopLevelDocument, http.StatusBadRequest)
+		return
+	}
+	req = contextWithFetchParamsValue(req, params)
+	resDoc.SetFieldsets(params.Fields)
+	resDoc.SetFullLinkage(mux.FullLinkage)
 
 	status := http.StatusOK
+	allowed := hand.allowed(route)
+	handler := chain(func(res Responder, req *http.Request, route Route) {
+		logContext(req)
+		if !hand.implements(req.Method, route) {
+			res.AppendError(mux.unimplemented(res, req.Method, route, allowed))
+			return
+		}
+		status = hand.serve(res, req, route, resDoc.TopLevelDocument)
+	}, mux.middleware, hand.middleware)
+	mux.callHandler(handler, resDoc, req, route)
+
+	if resDoc.written {
+		return
+	}
+	if resDoc.status != 0 {
+		status = resDoc.status
+	}
+	resDoc.applyMediaTypeParams(negotiated)
+	if len(resDoc.Errors) == 0 {
+		setLocationHeaders(res, resDoc.TopLevelDocument, status)
+	}
+
+	mux.writeDocument(res, req, resDoc.TopLevelDocument, status)
+}
+
+// UnimplementedPolicy returns the error to respond with when no handler is
+// registered for a request. allowed lists the methods with a registered
+// handler for the path of the route; it is empty when the endpoint is not
+// registered. Requests to unregistered endpoints result in 404 (not found).
+// Requests to create or update resources, or to update relationships, are
+// forbidden as the specification requires. Other requests result in 404
+// (not found) when no handler is registered for the path and 405 (method not
+// allowed) otherwise.
+func UnimplementedPolicy(method string, route Route, allowed []string) Error {
+	switch {
+	case len(allowed) == 0:
+		return errPathNotFound(route.Path())
+	case route.Action == ActionCreate || route.Action == ActionUpdate:
+		return ErrUnsupportedAction(route.Action)
+	case len(allowed) == 1 && allowed[0] == http.MethodOptions:
+		return errPathNotFound(route.Path())
+	default:
+		return ErrMethodNotAllowed(method)
+	}
+}
 
+// errPathNotFound returns the error for a request to p when no handler is
+// registered for it.
+func errPathNotFound(p string) Error {
+	return Error{
+		Status: http.StatusNotFound,
+		Code:   CodeNotFound,
+		Title:  "Not Found",
+		Detail: fmt.Sprintf("%s not found", p),
+	}
+}
+
+// unimplemented returns the error of the mux's unimplemented policy and sets
+// the Allow header when it is a 405 (method not allowed).
+func (mux ServeMux) unimplemented(res http.ResponseWriter, method string, route Route, allowed []string) Error {
+	policy := mux.UnimplementedPolicy
+	if policy == nil {
+		policy = UnimplementedPolicy
+	}
+	err := policy(method, route, allowed)
+	if err.Status == http.StatusMethodNotAllowed {
+		res.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	return err
+}
+
+// serve calls the handler for route and returns the status of a successful
+// response.
+func (hand EndpointHandler) serve(res Responder, req *http.Request, route Route, doc *TopLevelDocument) int {
 	switch req.Method {
-	case http.MethodGet:
-		hand.fetch.handle(resDoc, req)
+	case http.MethodGet, http.MethodHead:
+		hand.fetch.handle(res, req)
 	case http.MethodPost:
-		status = http.StatusCreated
-		if hand.create == nil {
-			res.WriteHeader(http.StatusForbidden)
-			return
+		if route.Action == ActionUpdate {
+			hand.update.handleAdd(res, req)
+			return relationshipsStatus(doc)
 		}
-		hand.create(resDoc, req)
+		req, err := hand.clientID.check(req)
+		if err != nil {
+			res.AppendError(err)
+			return http.StatusForbidden
+		}
+		hand.create(res, req)
+		return http.StatusCreated
 	case http.MethodPatch:
-		hand.update.handle(resDoc, req)
+		hand.update.handle(res, req)
+		if route.Relationships {
+			return relationshipsStatus(doc)
+		}
 	case http.MethodDelete:
+		if route.Action == ActionUpdate {
+			hand.update.handleRemove(res, req)
+			return relationshipsStatus(doc)
+		}
 		var id string
 		id, req.URL.Path = shiftPath(req.URL.Path)
-		hand.delete(resDoc, req, id)
+		hand.delete(res, req, id)
+		return deleteStatus(doc)
+	}
+	return http.StatusOK
+}
+
+// implements reports whether the handler serve calls for method and route
+// is registered.
+func (hand EndpointHandler) implements(method string, route Route) bool {
+	return containsString(hand.allowed(route), method)
+}
+
+// allowed returns the methods with a handler registered for the path of
+// route.
+func (hand EndpointHandler) allowed(route Route) []string {
+	var methods []string
+	add := func(method string, registered bool) {
+		if registered {
+			methods = append(methods, method)
+		}
+	}
+	switch {
+	case route.ID == "":
+		add(http.MethodGet, hand.fetch.col != nil)
+		add(http.MethodPost, hand.create != nil)
+	case route.Relation == "":
+		add(http.MethodGet, hand.fetch.one != nil)
+		add(http.MethodPatch, hand.update.one != nil)
+		add(http.MethodDelete, hand.delete != nil)
+	case route.Relationships:
+		add(http.MethodGet, hand.fetch.relationships[route.Relation] != nil)
+		add(http.MethodPost, hand.update.add[route.Relation] != nil)
+		add(http.MethodPatch, hand.update.relationships[route.Relation] != nil)
+		add(http.MethodDelete, hand.update.remove[route.Relation] != nil)
 	default:
-		res.WriteHeader(http.StatusMethodNotAllowed)
+		add(http.MethodGet, hand.fetch.related[route.Relation] != nil)
+	}
+	if len(methods) != 0 && methods[0] == http.MethodGet {
+		methods = append([]string{http.MethodGet, http.MethodHead}, methods[1:]...)
+	}
+	return append(methods, http.MethodOptions)
+}
+
+// writeOptions responds to an OPTIONS request with the allowed methods.
+func writeOptions(res http.ResponseWriter, allowed []string) {
+	res.Header().Set("Allow", strings.Join(allowed, ", "))
+	res.Header().Del("Content-Type")
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// writeMethodNotAllowed responds with the allowed methods and an
+// ErrMethodNotAllowed.
+func (mux ServeMux) writeMethodNotAllowed(res http.ResponseWriter, req *http.Request, allowed []string) {
+	res.Header().Set("Allow", strings.Join(allowed, ", "))
+	mux.writeError(res, req, ErrMethodNotAllowed(req.Method))
+}
+
+// bodylessResponseWriter discards the body of a response to a HEAD request.
+type bodylessResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (res bodylessResponseWriter) Write(buf []byte) (int, error) {
+	return len(buf), nil
+}
+
+// setLocationHeaders sets the Location header of a 201 Created response and
+// the Content-Location header of a 202 Accepted response from the self link
+// of the primary data.
+func setLocationHeaders(res http.ResponseWriter, doc *TopLevelDocument, status int) {
+	var header string
+	switch status {
+	case http.StatusCreated:
+		header = "Location"
+	case http.StatusAccepted:
+		header = "Content-Location"
+	default:
+		return
+	}
+	resource, ok := doc.Data.(*Resource)
+	if !ok {
 		return
 	}
+	self := resource.Links["self"]
+	if self.Object.HREF != "" {
+		res.Header().Set(header, self.Object.HREF)
+	} else if self.String != "" {
+		res.Header().Set(header, self.String)
+	}
+}
 
-	if len(resDoc.TopLevelDocument.Errors) != 0 {
-		status = ErrorsPolicy(resDoc.TopLevelDocument.Errors)
+// writeDocument encodes doc as the response body. When doc has errors the
+// status is derived from them using the mux's errors policy.
+func (mux ServeMux) writeDocument(res http.ResponseWriter, req *http.Request, doc *TopLevelDocument, status int) {
+	doc.reportFullLinkage()
+	if len(doc.Errors) != 0 {
+		policy := mux.ErrorsPolicy
+		if policy == nil {
+			policy = ErrorsPolicy
+		}
+		status = policy(doc.Errors)
 	}
 
-	marshaledDoc, err := json.Marshal(resDoc.TopLevelDocument)
-	if err != nil {
+	if status == http.StatusNoContent {
+		res.Header().Del("Content-Type")
+		res.WriteHeader(status)
+		return
+	}
+
+	var (
+		marshaledDoc []byte
+		err          error
+	)
+	internal, panicked := mux.protect(req, func() {
+		marshaledDoc, err = json.Marshal(doc)
+	})
+	if panicked || err != nil {
+		if err != nil {
+			mux.logger().LogAttrs(req.Context(), slog.LevelError, "jsonapi response document could not be encoded", slog.String("error", err.Error()))
+			internal = Error{Detail: "response could not be rendered", Status: http.StatusInternalServerError}
+		}
 		status = http.StatusInternalServerError
 
-		var doc TopLevelDocument
-		doc.AppendError(Error{Detail: "response could not be rendered", Status: http.StatusInternalServerError})
+		doc = &TopLevelDocument{}
+		doc.AppendError(internal)
 		marshaledDoc, err = json.Marshal(doc)
 		if err != nil {
-			log.Println(`{"errors": [{"detail": "top level document response could not be encoded"}]}`, err)
+			mux.logger().LogAttrs(req.Context(), slog.LevelError, "jsonapi error document could not be encoded", slog.String("error", err.Error()))
 		}
 	}
+	logErrors(req, doc.Errors)
 
 	res.WriteHeader(status)
 	res.Write(marshaledDoc)
 }
 
+// writeError responds with a document containing only err.
+func (mux ServeMux) writeError(res http.ResponseWriter, req *http.Request, err error) {
+	var doc TopLevelDocument
+	doc.SetInternalErrorRedaction(mux.RedactInternalErrors)
+	doc.AppendError(err)
+	mux.writeDocument(res, req, &doc, http.StatusInternalServerError)
+}
+
+// relationshipsStatus returns 204 No Content when a relationship update
+// handler did not set any data, including null, and 200 OK otherwise.
+func relationshipsStatus(doc *TopLevelDocument) int {
+	if doc.Data == nil && doc.resourceSlice == nil && !doc.nullData {
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// deleteStatus returns 204 No Content when a delete handler did not set any
+// data or meta and 200 OK otherwise. When only meta is set, data is omitted
+// from the response document.
+func deleteStatus(doc *TopLevelDocument) int {
+	if doc.Data != nil || doc.resourceSlice != nil {
+		return http.StatusOK
+	}
+	doc.omitData = true
+	if len(doc.Meta) == 0 {
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
 func shiftPath(p string) (head, tail string) {
 	p = path.Clean("/" + p)
 	i := strings.Index(p[1:], "/") + 1
@@ -110,11 +423,13 @@ func (mux *ServeMux) initResources() {
 // EndpointHandler encapsulates fetch, create, update, and delete handlers
 // for a single endpoint
 type EndpointHandler struct {
-	// PermitClientGeneratedID bool
-	fetch  fetchHandler
-	create CreateFunc
-	update updateHandler
-	delete DeleteFunc
+	fetch    fetchHandler
+	create   CreateFunc
+	clientID clientIDPolicy
+	update   updateHandler
+	delete   DeleteFunc
+
+	middleware []Middleware
 }
 
 // HandleFetchOne should be used to set and endpoint handler for
@@ -135,6 +450,88 @@ func (mux *ServeMux) HandleFetchCollection(endpoint string, fn FetchCollectionFu
 	mux.Resources[endpoint] = handler
 }
 
+// HandleFetchRelated should be used to set and endpoint handler for
+// GET `/:endpoint/:id/:relation`
+func (mux *ServeMux) HandleFetchRelated(endpoint, relation string, fn FetchRelatedFunc) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	if handler.fetch.related == nil {
+		handler.fetch.related = make(map[string]FetchRelatedFunc)
+	}
+	handler.fetch.related[relation] = fn
+	mux.Resources[endpoint] = handler
+}
+
+// HandleFetchRelationships should be used to set and endpoint handler for
+// GET `/:endpoint/:id/relationships/:relation`
+func (mux *ServeMux) HandleFetchRelationships(endpoint, relation string, fn FetchRelationshipsFunc) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	if handler.fetch.relationships == nil {
+		handler.fetch.relationships = make(map[string]FetchRelationshipsFunc)
+	}
+	handler.fetch.relationships[relation] = fn
+	mux.Resources[endpoint] = handler
+}
+
+// Related returns the sorted names of relations with a FetchRelatedFunc
+// registered for the endpoint.
+func (hand EndpointHandler) Related() []string {
+	names := make([]string, 0, len(hand.fetch.related))
+	for name := range hand.fetch.related {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Relationships returns the sorted names of relations with a
+// FetchRelationshipsFunc registered for the endpoint.
+func (hand EndpointHandler) Relationships() []string {
+	names := make([]string, 0, len(hand.fetch.relationships))
+	for name := range hand.fetch.relationships {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HandleUpdateRelationships should be used to set and endpoint handler for
+// PATCH `/:endpoint/:id/relationships/:relation`
+func (mux *ServeMux) HandleUpdateRelationships(endpoint, relation string, fn UpdateRelationshipsFunc) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	if handler.update.relationships == nil {
+		handler.update.relationships = make(map[string]UpdateRelationshipsFunc)
+	}
+	handler.update.relationships[relation] = fn
+	mux.Resources[endpoint] = handler
+}
+
+// HandleAddToMany should be used to set and endpoint handler for
+// POST `/:endpoint/:id/relationships/:relation`
+func (mux *ServeMux) HandleAddToMany(endpoint, relation string, fn AddToManyFunc) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	if handler.update.add == nil {
+		handler.update.add = make(map[string]AddToManyFunc)
+	}
+	handler.update.add[relation] = fn
+	mux.Resources[endpoint] = handler
+}
+
+// HandleRemoveToMany should be used to set and endpoint handler for
+// DELETE `/:endpoint/:id/relationships/:relation`
+func (mux *ServeMux) HandleRemoveToMany(endpoint, relation string, fn RemoveToManyFunc) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	if handler.update.remove == nil {
+		handler.update.remove = make(map[string]RemoveToManyFunc)
+	}
+	handler.update.remove[relation] = fn
+	mux.Resources[endpoint] = handler
+}
+
 // HandleCreate should be used to set and endpoint handler for
 // POST `/:endpoint`
 func (mux *ServeMux) HandleCreate(endpoint string, fn CreateFunc) {
@@ -144,6 +541,17 @@ func (mux *ServeMux) HandleCreate(endpoint string, fn CreateFunc) {
 	mux.Resources[endpoint] = handler
 }
 
+// PermitClientGeneratedIDs allows requests to create a resource at endpoint
+// to include an id. If validate is not nil, it is called with each client
+// generated id and the request is forbidden when it returns an error. By
+// default requests including an id are forbidden.
+func (mux *ServeMux) PermitClientGeneratedIDs(endpoint string, validate ClientIDValidator) {
+	mux.initResources()
+	handler := mux.Resources[endpoint]
+	handler.clientID = clientIDPolicy{permit: true, validate: validate}
+	mux.Resources[endpoint] = handler
+}
+
 // HandleUpdate should be used to set and endpoint handler for
 // PATCH `/:endpoint/:id`
 func (mux *ServeMux) HandleUpdate(endpoint string, fn UpdateFunc) {
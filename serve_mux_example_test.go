@@ -36,25 +36,18 @@ func ExampleServeMux() {
 
 	var issues []Issue
 
-	issueTypes := map[string]struct{}{"bug": struct{}{}, "feature": struct{}{}, "chore": struct{}{}}
+	decoder := jsonapi.RequestDecoder{Types: []string{"bug", "feature", "chore"}}
 
 	mux.HandleCreate(issuesEndpoint, jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
-		var body jsonapi.CreateRequestData
-
-		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
-			res.AppendError(jsonapi.Error{Detail: err.Error(), Status: http.StatusBadRequest})
-			return
-		}
-
-		if _, ok := issueTypes[body.Data.Type]; !ok {
-			detail := fmt.Sprintf("%q is not among the type(s) that constitute the collection represented by the endpoint", body.Data.Type)
-			res.AppendError(jsonapi.Error{Detail: detail, Status: http.StatusConflict})
+		body, err := decoder.DecodeCreate(req)
+		if err != nil {
+			res.AppendError(err)
 			return
 		}
 
 		var issue Issue
-		if err := json.Unmarshal(body.Data.Attributes, &issue); err != nil {
-			res.AppendError(jsonapi.Error{Detail: err.Error(), Status: http.StatusBadRequest})
+		if err := body.UnmarshalAttributes(&issue); err != nil {
+			res.AppendError(err)
 			return
 		}
 		issue.Type = body.Data.Type
@@ -67,7 +60,7 @@ func ExampleServeMux() {
 	mux.HandleFetchOne(issuesEndpoint, jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, idStr string) {
 		id, err := strconv.Atoi(idStr)
 		if err != nil || id < 0 || id >= len(issues) {
-			res.AppendError(jsonapi.Error{Detail: fmt.Sprintf("%q not found", idStr), Status: http.StatusNotFound})
+			res.AppendError(jsonapi.ErrNotFound(issuesEndpoint, idStr))
 			return
 		}
 		issue := issues[id]
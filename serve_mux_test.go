@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/crhntr/jsonapi"
@@ -72,9 +74,9 @@ func TestHandle_ServeHTTP_TopLevelAndContentNegotiation(t *testing.T) {
 
 		// Test Expectaions
 		result := res.Result()
-		if result.StatusCode != http.StatusNotAcceptable {
-			t.Error("It should return http status not acceptable")
-			t.Logf("instead got %q", result.StatusCode)
+		if result.StatusCode != http.StatusOK {
+			t.Error("It should accept any media type")
+			t.Logf("instead got %d", result.StatusCode)
 		}
 	})
 
@@ -103,7 +105,7 @@ func TestHandle_ServeHTTP_TopLevelAndContentNegotiation(t *testing.T) {
 
 	t.Run("When Content-Type Request Header is Empty", func(t *testing.T) {
 		// Setup
-		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"data": null}`))
 		if err != nil {
 			t.Error(err)
 		}
@@ -388,9 +390,8 @@ func TestHandle_ServeHTTP_RequestMux_Fetching(t *testing.T) {
 			t.Error("it should return a document with member called data")
 			t.Log(string(bodyBuf))
 		}
-		_, dataMemberValueIsObject := dataMemberValue.(map[string]interface{})
-		if !dataMemberValueIsObject {
-			t.Error(`it should have document member data that is an object`)
+		if dataMemberValue != nil {
+			t.Error(`it should have document member data that is null`)
 			t.Log(string(bodyBuf))
 		}
 	})
@@ -398,7 +399,7 @@ func TestHandle_ServeHTTP_RequestMux_Fetching(t *testing.T) {
 
 func TestHandle_ServeHTTP_RequestMux_Creating(t *testing.T) {
 	t.Run("When creating a single resource", func(t *testing.T) {
-		req, err := jsonapi.NewRequest(http.MethodPost, "/resource/n", nil)
+		req, err := jsonapi.NewRequest(http.MethodPost, "/resource", nil)
 		mustNotErr(t, err)
 		res := httptest.NewRecorder()
 
@@ -525,3 +526,987 @@ type errorAttr struct{}
 func (attr errorAttr) MarshalJSON() ([]byte, error) {
 	return nil, errors.New("some-err")
 }
+
+func TestHandle_ServeHTTP_RequestMux_FetchingRelated(t *testing.T) {
+	t.Run("When fetching a related resource", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1/author", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+
+		var (
+			recievedEndpoint, recievedID, recievedRelation string
+		)
+		mux.HandleFetchRelated("articles", "author", jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {
+			recievedEndpoint = jsonapi.Endpoint(req.Context())
+			recievedID, recievedRelation = id, relation
+			res.SetData("people", "9", map[string]string{"name": "Dan"}, nil, nil, nil)
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if recievedEndpoint != "articles" {
+			t.Error("it should recieve the correct endpoint parameter")
+			t.Log(recievedEndpoint)
+		}
+		if recievedID != "1" || recievedRelation != "author" {
+			t.Error("it should recieve the id and relation from the path")
+			t.Log(recievedID, recievedRelation)
+		}
+		if res.Code != http.StatusOK {
+			t.Error("it should respond with status ok")
+			t.Log(res.Code)
+		}
+
+		var doc struct {
+			Data struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+			t.Error("it should return an object")
+			t.Log(err)
+		}
+		if doc.Data.ID != "9" || doc.Data.Type != "people" {
+			t.Error("it should render the related resource")
+			t.Log(res.Body.String())
+		}
+	})
+
+	for _, tt := range []struct {
+		name, relation string
+		respond        func(res jsonapi.FetchRelatedResponder)
+		result         string
+	}{
+		{"an empty to-one related resource", "author", func(res jsonapi.FetchRelatedResponder) { res.SetNull() }, `{"data":null}`},
+		{"an empty to-many related collection", "comments", func(res jsonapi.FetchRelatedResponder) { res.SetDataCollection() }, `{"data":[]}`},
+		{"a to-one related resource", "author", func(res jsonapi.FetchRelatedResponder) {
+			res.SetData("people", "9", nil, nil, nil, nil)
+		}, `{"data":{"id":"9","type":"people"}}`},
+		{"a to-many related collection", "comments", func(res jsonapi.FetchRelatedResponder) {
+			res.AppendData("comments", "5", nil, nil, nil, nil)
+		}, `{"data":[{"id":"5","type":"comments"}]}`},
+	} {
+		t.Run("When fetching "+tt.name, func(t *testing.T) {
+			req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1/"+tt.relation, nil)
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			var mux jsonapi.ServeMux
+			mux.HandleFetchRelated("articles", tt.relation, jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {
+				tt.respond(res)
+			}))
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if body := res.Body.String(); body != tt.result {
+				t.Error("it should encode the expected primary data")
+				t.Log(body)
+			}
+		})
+	}
+
+	t.Run("When fetching an unregistered related resource", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1/comments", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleFetchRelated("articles", "author", jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusNotFound {
+			t.Error("it should respond with status not found")
+			t.Log(res.Code)
+		}
+	})
+
+	t.Run("When fetching relationships", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1/relationships/tags", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+
+		var recievedID, recievedRelation string
+		mux.HandleFetchRelationships("articles", "tags", jsonapi.FetchRelationshipsFunc(func(res jsonapi.FetchRelationshipsResponder, req *http.Request, id, relation string) {
+			recievedID, recievedRelation = id, relation
+			res.AppendIdentity("tags", "2")
+			res.AppendIdentity("tags", "3")
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if recievedID != "1" || recievedRelation != "tags" {
+			t.Error("it should recieve the id and relation from the path")
+			t.Log(recievedID, recievedRelation)
+		}
+
+		var doc struct {
+			Data []jsonapi.Identity `json:"data"`
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+			t.Error("it should return an object")
+			t.Log(err)
+		}
+		if len(doc.Data) != 2 || doc.Data[0] != (jsonapi.Identity{ID: "2", Type: "tags"}) {
+			t.Error("it should render the resource identities")
+			t.Log(res.Body.String())
+		}
+	})
+
+	t.Run("When registered relations are listed", func(t *testing.T) {
+		var mux jsonapi.ServeMux
+		noopRelated := jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {})
+		noopRelationships := jsonapi.FetchRelationshipsFunc(func(res jsonapi.FetchRelationshipsResponder, req *http.Request, id, relation string) {})
+		mux.HandleFetchRelated("articles", "comments", noopRelated)
+		mux.HandleFetchRelated("articles", "author", noopRelated)
+		mux.HandleFetchRelationships("articles", "tags", noopRelationships)
+
+		hand := mux.Resources["articles"]
+
+		if related := hand.Related(); len(related) != 2 || related[0] != "author" || related[1] != "comments" {
+			t.Error("it should list the related relations in order")
+			t.Log(related)
+		}
+		if relationships := hand.Relationships(); len(relationships) != 1 || relationships[0] != "tags" {
+			t.Error("it should list the relationships relations")
+			t.Log(relationships)
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_RequestMux_UpdatingRelationships(t *testing.T) {
+	noopAdd := jsonapi.AddToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+	})
+
+	t.Run("When adding to a to-many relationship", func(t *testing.T) {
+		reqBody := `{"data": [{"type": "tags", "id": "2"}, {"type": "tags", "id": "3"}]}`
+		req, err := jsonapi.NewRequest(http.MethodPost, "/articles/1/relationships/tags", strings.NewReader(reqBody))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var (
+			mux                          jsonapi.ServeMux
+			recievedID, recievedRelation string
+			recievedIdentities           []jsonapi.Identity
+		)
+		mux.HandleCreate("articles", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+			t.Error("it should not call the create handler")
+		}))
+		mux.HandleAddToMany("articles", "tags", jsonapi.AddToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+			recievedID, recievedRelation, recievedIdentities = id, relation, identities
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if recievedID != "1" || recievedRelation != "tags" {
+			t.Error("it should recieve the id and relation from the path")
+			t.Log(recievedID, recievedRelation)
+		}
+		if len(recievedIdentities) != 2 || recievedIdentities[1] != (jsonapi.Identity{ID: "3", Type: "tags"}) {
+			t.Error("it should recieve the identities from the request body")
+			t.Log(recievedIdentities)
+		}
+		if res.Code != http.StatusNoContent {
+			t.Error("it should respond with status no content")
+			t.Log(res.Code)
+		}
+		if res.Body.Len() != 0 {
+			t.Error("it should not have a response body")
+			t.Log(res.Body.String())
+		}
+	})
+
+	t.Run("When removing from a to-many relationship and responding with the linkage", func(t *testing.T) {
+		reqBody := `{"data": [{"type": "tags", "id": "2"}]}`
+		req, err := jsonapi.NewRequest(http.MethodDelete, "/articles/1/relationships/tags", strings.NewReader(reqBody))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {
+			t.Error("it should not call the delete handler")
+		}))
+		mux.HandleRemoveToMany("articles", "tags", jsonapi.RemoveToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+			res.SetDataCollection()
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusOK {
+			t.Error("it should respond with status ok")
+			t.Log(res.Code)
+		}
+		if body := res.Body.String(); body != `{"data":[]}` {
+			t.Error("it should render the remaining linkage")
+			t.Log(body)
+		}
+	})
+
+	t.Run("When replacing a relationship", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodPatch, "/articles/1/relationships/author", strings.NewReader(`{"data": {"type": "people", "id": "2"}}`))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var (
+			mux       jsonapi.ServeMux
+			callCount int
+		)
+		mux.HandleUpdateRelationships("articles", "author", jsonapi.UpdateRelationshipsFunc(func(res jsonapi.UpdateRelationshipsResponder, req *http.Request, id, relation string) {
+			callCount++
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if callCount != 1 {
+			t.Error("it should call the update relationships handler")
+		}
+		if res.Code != http.StatusNoContent {
+			t.Error("it should respond with status no content")
+			t.Log(res.Code)
+		}
+	})
+
+	t.Run("When adding to an unsupported relationship", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodPost, "/articles/1/relationships/comments", strings.NewReader(`{"data": []}`))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleAddToMany("articles", "tags", noopAdd)
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusForbidden {
+			t.Error("it should respond with status forbidden")
+			t.Log(res.Code)
+		}
+	})
+
+	t.Run("When the handler reports a conflict", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodPost, "/articles/1/relationships/tags", strings.NewReader(`{"data": [{"type": "people", "id": "2"}]}`))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleAddToMany("articles", "tags", jsonapi.AddToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+			res.AppendError(jsonapi.Error{Status: http.StatusConflict, Detail: "people are not tags"})
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusConflict {
+			t.Error("it should respond with status conflict")
+			t.Log(res.Code)
+		}
+	})
+
+	t.Run("When the request body is not an array of identities", func(t *testing.T) {
+		for _, reqBody := range []string{
+			``,
+			`{"data": {"type": "tags", "id": "2"}}`,
+			`{"data": [{"type": "tags"}]}`,
+		} {
+			req, err := jsonapi.NewRequest(http.MethodPost, "/articles/1/relationships/tags", strings.NewReader(reqBody))
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			var mux jsonapi.ServeMux
+			mux.HandleAddToMany("articles", "tags", jsonapi.AddToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+				t.Error("it should not call the handler")
+			}))
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != http.StatusBadRequest {
+				t.Error("it should respond with status bad request")
+				t.Log(reqBody)
+				t.Log(res.Code)
+			}
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_RequestMux_TopLevelMembers(t *testing.T) {
+	t.Run("When a handler sets top level links and meta", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {
+			res.SetLinks(jsonapi.Links{"self": jsonapi.Link{String: "/articles"}})
+			res.SetMeta(jsonapi.Meta{"total": 0})
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if body := res.Body.String(); body != `{"data":[],"links":{"self":"/articles"},"meta":{"total":0}}` {
+			t.Error("it should render the top level links and meta")
+			t.Log(body)
+		}
+	})
+
+	t.Run("When a handler sets the jsonapi object", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+			res.SetData("articles", id, nil, nil, nil, nil)
+			res.SetJSONAPIObject(jsonapi.JSONAPIObject{Version: "1.1", Meta: jsonapi.Meta{"build": "abc"}})
+		}))
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if body := res.Body.String(); body != `{"data":{"id":"1","type":"articles"},"jsonapi":{"version":"1.1","meta":{"build":"abc"}}}` {
+			t.Error("it should render the jsonapi object")
+			t.Log(body)
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_ErrorsPolicy(t *testing.T) {
+	handler := jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		res.AppendError(jsonapi.Error{Status: http.StatusForbidden})
+		res.AppendError(jsonapi.Error{Status: http.StatusNotFound})
+	})
+
+	t.Run("When the default policy is used", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var mux jsonapi.ServeMux
+		mux.HandleFetchOne("articles", handler)
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusBadRequest {
+			t.Error("it should respond with status bad request")
+			t.Log(res.Code)
+		}
+	})
+
+	t.Run("When a custom policy is set", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		var recievedErrors []jsonapi.Error
+		mux := jsonapi.ServeMux{ErrorsPolicy: func(errors []jsonapi.Error) int {
+			recievedErrors = errors
+			return errors[len(errors)-1].Status
+		}}
+		mux.HandleFetchOne("articles", handler)
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if len(recievedErrors) != 2 {
+			t.Error("it should pass the errors to the policy")
+		}
+		if res.Code != http.StatusNotFound {
+			t.Error("it should respond with the status from the policy")
+			t.Log(res.Code)
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_RedactInternalErrors(t *testing.T) {
+	req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+	mustNotErr(t, err)
+	res := httptest.NewRecorder()
+
+	mux := jsonapi.ServeMux{RedactInternalErrors: true}
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		res.AppendError(errors.New("pq: password authentication failed"))
+	}))
+
+	// Run
+	mux.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Error("it should respond with status internal server error")
+		t.Log(res.Code)
+	}
+	if body := res.Body.String(); body != `{"errors":[{"detail":"Internal Server Error"}]}` {
+		t.Error("it should not expose the error detail")
+		t.Log(body)
+	}
+}
+
+func TestHandle_ServeHTTP_ClientGeneratedIDs(t *testing.T) {
+	const uuid = "2cbdf2a6-5a3e-4a0a-9b7d-3f3c1c1ae0f4"
+
+	existing := map[string]bool{uuid: true}
+
+	newMux := func(permit bool, validate jsonapi.ClientIDValidator) *jsonapi.ServeMux {
+		mux := &jsonapi.ServeMux{}
+		mux.HandleCreate("photos", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+			data, err := jsonapi.DecodeCreateRequest(req)
+			if err != nil {
+				res.AppendError(err)
+				return
+			}
+			id := jsonapi.ClientGeneratedID(req.Context())
+			if id != data.Data.ID {
+				t.Error("it should pass the client generated id in the context")
+			}
+			if existing[id] {
+				res.AppendError(jsonapi.ErrIDConflict(id))
+				return
+			}
+			if id == "" {
+				id = "generated"
+			}
+			res.SetData("photos", id, nil, nil, nil, nil)
+		}))
+		if permit {
+			mux.PermitClientGeneratedIDs("photos", validate)
+		}
+		return mux
+	}
+
+	for _, tt := range []struct {
+		name     string
+		mux      *jsonapi.ServeMux
+		body     string
+		status   int
+		response string
+	}{
+		{"without an id when ids are forbidden", newMux(false, nil), `{"data": {"type": "photos"}}`, http.StatusCreated, `{"data":{"id":"generated","type":"photos"}}`},
+		{"with an id when ids are forbidden", newMux(false, nil), `{"data": {"type": "photos", "id": "1"}}`, http.StatusForbidden, `{"errors":[{"status":"403","code":"client-id-forbidden","title":"Client Generated ID Forbidden","detail":"client generated IDs are not supported","source":{"pointer":"/data/id"}}]}`},
+		{"with an id when ids are permitted", newMux(true, nil), `{"data": {"type": "photos", "id": "1"}}`, http.StatusCreated, `{"data":{"id":"1","type":"photos"}}`},
+		{"without an id when ids are permitted", newMux(true, nil), `{"data": {"type": "photos"}}`, http.StatusCreated, `{"data":{"id":"generated","type":"photos"}}`},
+		{"with a valid id", newMux(true, jsonapi.ValidateUUID), `{"data": {"type": "photos", "id": "7d6a6f5e-2a43-4a53-8f3e-0c1d2e3f4a5b"}}`, http.StatusCreated, `{"data":{"id":"7d6a6f5e-2a43-4a53-8f3e-0c1d2e3f4a5b","type":"photos"}}`},
+		{"with an invalid id", newMux(true, jsonapi.ValidateUUID), `{"data": {"type": "photos", "id": "1"}}`, http.StatusForbidden, `{"errors":[{"status":"403","code":"client-id-forbidden","title":"Client Generated ID Forbidden","detail":"client generated ids must be UUIDs","source":{"pointer":"/data/id"}}]}`},
+		{"with an id that already exists", newMux(true, jsonapi.ValidateUUID), `{"data": {"type": "photos", "id": "` + uuid + `"}}`, http.StatusConflict, `{"errors":[{"status":"409","code":"id-conflict","title":"ID Conflict","detail":"a resource with id \"` + uuid + `\" already exists","source":{"pointer":"/data/id"}}]}`},
+	} {
+		t.Run("When creating "+tt.name, func(t *testing.T) {
+			req, err := jsonapi.NewRequest(http.MethodPost, "/photos", strings.NewReader(tt.body))
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			// Run
+			tt.mux.ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("it should respond with status %d", tt.status)
+				t.Log(res.Code)
+			}
+			if body := res.Body.String(); body != tt.response {
+				t.Error("it should respond with the expected document")
+				t.Log(body)
+			}
+		})
+	}
+
+	t.Run("When ids are only permitted by the request decoder", func(t *testing.T) {
+		var (
+			mux    jsonapi.ServeMux
+			called bool
+		)
+		mux.HandleCreate("photos", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+			called = true
+			data, err := jsonapi.RequestDecoder{PermitClientGeneratedID: true}.DecodeCreate(req)
+			if err != nil {
+				res.AppendError(err)
+				return
+			}
+			res.SetData("photos", data.Data.ID, nil, nil, nil, nil)
+		}))
+
+		req, err := jsonapi.NewRequest(http.MethodPost, "/photos", strings.NewReader(`{"data": {"type": "photos", "id": "1"}}`))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusForbidden {
+			t.Error("it should forbid the id as the endpoint does not permit it")
+			t.Log(res.Code)
+		}
+		if called {
+			t.Error("it should not call the handler")
+		}
+	})
+}
+
+func TestValidateUUID(t *testing.T) {
+	for _, id := range []string{"2cbdf2a6-5a3e-4a0a-9b7d-3f3c1c1ae0f4", "2CBDF2A6-5A3E-4A0A-9B7D-3F3C1C1AE0F4"} {
+		if err := jsonapi.ValidateUUID(id); err != nil {
+			t.Error("it should accept a uuid")
+			t.Log(id)
+		}
+	}
+	for _, id := range []string{"", "1", "2cbdf2a65a3e4a0a9b7d3f3c1c1ae0f4", "2cbdf2a6-5a3e-4a0a-9b7d-3f3c1c1ae0fg", "{2cbdf2a6-5a3e-4a0a-9b7d-3f3c1c1ae0f}"} {
+		if err := jsonapi.ValidateUUID(id); err == nil {
+			t.Error("it should reject an id that is not a uuid")
+			t.Log(id)
+		}
+	}
+}
+
+func TestHandle_ServeHTTP_StatusCodes(t *testing.T) {
+	var mux jsonapi.ServeMux
+	mux.HandleCreate("photos", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+		switch req.URL.Query().Get("filter[respond]") {
+		case "no-content":
+			res.SetStatus(http.StatusNoContent)
+		case "accepted":
+			res.SetData("jobs", "5", nil, nil, jsonapi.Links{"self": {String: "/jobs/5"}}, nil)
+			res.SetStatus(http.StatusAccepted)
+		case "changed":
+			res.SetData("photos", "1", map[string]string{"title": "changed"}, nil, jsonapi.Links{"self": {String: "/photos/1"}}, nil)
+			res.SetStatus(http.StatusOK)
+		default:
+			res.SetData("photos", "1", nil, nil, jsonapi.Links{"self": {String: "/photos/1"}}, nil)
+		}
+	}))
+	mux.HandleUpdate("photos", jsonapi.UpdateFunc(func(res jsonapi.UpdateResponder, req *http.Request, id string) {
+		res.SetStatus(http.StatusNoContent)
+	}))
+	mux.HandleDelete("photos", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {
+		switch id {
+		case "meta":
+			res.SetMeta(jsonapi.Meta{"deleted": 1})
+		case "accepted":
+			res.SetData("jobs", "6", nil, nil, nil, nil)
+			res.SetStatus(http.StatusAccepted)
+		case "missing":
+			res.AppendError(jsonapi.ErrNotFound("photos", id))
+		}
+	}))
+
+	for _, tt := range []struct {
+		name, method, path, body string
+
+		status          int
+		response        string
+		location        string
+		contentLocation string
+	}{
+		{"creating a resource", http.MethodPost, "/photos", `{"data":{"type":"photos"}}`, http.StatusCreated, `{"data":{"id":"1","type":"photos","links":{"self":"/photos/1"}}}`, "/photos/1", ""},
+		{"creating a resource without a response document", http.MethodPost, "/photos?filter[respond]=no-content", `{"data":{"type":"photos"}}`, http.StatusNoContent, "", "", ""},
+		{"creating a resource asynchronously", http.MethodPost, "/photos?filter[respond]=accepted", `{"data":{"type":"photos"}}`, http.StatusAccepted, `{"data":{"id":"5","type":"jobs","links":{"self":"/jobs/5"}}}`, "", "/jobs/5"},
+		{"creating a resource the server changed", http.MethodPost, "/photos?filter[respond]=changed", `{"data":{"type":"photos"}}`, http.StatusOK, `{"data":{"id":"1","type":"photos","attributes":{"title":"changed"},"links":{"self":"/photos/1"}}}`, "", ""},
+		{"updating a resource without a response document", http.MethodPatch, "/photos/1", `{"data":{"type":"photos","id":"1"}}`, http.StatusNoContent, "", "", ""},
+		{"deleting a resource", http.MethodDelete, "/photos/1", "", http.StatusNoContent, "", "", ""},
+		{"deleting a resource with meta", http.MethodDelete, "/photos/meta", "", http.StatusOK, `{"meta":{"deleted":1}}`, "", ""},
+		{"deleting a resource asynchronously", http.MethodDelete, "/photos/accepted", "", http.StatusAccepted, `{"data":{"id":"6","type":"jobs"}}`, "", ""},
+		{"deleting a resource that does not exist", http.MethodDelete, "/photos/missing", "", http.StatusNotFound, `{"errors":[{"status":"404","code":"not-found","title":"Not Found","detail":"photos \"missing\" not found"}]}`, "", ""},
+	} {
+		t.Run("When "+tt.name, func(t *testing.T) {
+			req, err := jsonapi.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("it should respond with status %d", tt.status)
+				t.Log(res.Code)
+			}
+			if body := res.Body.String(); body != tt.response {
+				t.Error("it should respond with the expected body")
+				t.Log(body)
+			}
+			if location := res.Header().Get("Location"); location != tt.location {
+				t.Error("it should set the location header from the self link of a created resource")
+				t.Log(location)
+			}
+			if location := res.Header().Get("Content-Location"); location != tt.contentLocation {
+				t.Error("it should set the content location header from the self link of an accepted job")
+				t.Log(location)
+			}
+			if tt.status == http.StatusNoContent && res.Header().Get("Content-Type") != "" {
+				t.Error("it should not set a content type without a body")
+			}
+		})
+	}
+}
+
+func TestHandle_ServeHTTP_ContentNegotiation(t *testing.T) {
+	var mux jsonapi.ServeMux
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		res.SetData("articles", id, nil, nil, nil, nil)
+	}))
+	mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {}))
+	mux.HandleCreate("articles", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+		res.SetData("articles", "1", nil, nil, nil, nil)
+	}))
+
+	for _, tt := range []struct {
+		name, method, accept, contentType, body string
+
+		status int
+		code   string
+	}{
+		{"accepting the media type", http.MethodGet, jsonapi.ContentType, "", "", http.StatusOK, ""},
+		{"accepting a list including the media type", http.MethodGet, "text/html, application/vnd.api+json", "", "", http.StatusOK, ""},
+		{"accepting any media type", http.MethodGet, "*/*", "", "", http.StatusOK, ""},
+		{"accepting any application media type", http.MethodGet, "text/html;q=0.9, application/*;q=0.8", "", "", http.StatusOK, ""},
+		{"accepting the media type with a profile", http.MethodGet, `application/vnd.api+json; profile="https://example.com/profiles/flexible"`, "", "", http.StatusOK, ""},
+		{"accepting the media type with q of zero", http.MethodGet, "application/vnd.api+json;q=0, */*", "", "", http.StatusNotAcceptable, jsonapi.CodeNotAcceptable},
+		{"accepting only other media types", http.MethodGet, "text/html, application/json", "", "", http.StatusNotAcceptable, jsonapi.CodeNotAcceptable},
+		{"accepting only unsupported parameters", http.MethodGet, "application/vnd.api+json; charset=utf-8, application/vnd.api+json; ext=\"https://example.com/ext\"", "", "", http.StatusNotAcceptable, jsonapi.CodeNotAcceptable},
+		{"accepting unsupported parameters and a wildcard", http.MethodGet, "application/vnd.api+json; charset=utf-8, */*", "", "", http.StatusNotAcceptable, jsonapi.CodeNotAcceptable},
+		{"accepting some unsupported parameters", http.MethodGet, "application/vnd.api+json; charset=utf-8, application/vnd.api+json", "", "", http.StatusOK, ""},
+		{"deleting without a content type", http.MethodDelete, jsonapi.ContentType, "", "", http.StatusNoContent, ""},
+		{"creating without a content type", http.MethodPost, jsonapi.ContentType, "", `{"data":{"type":"articles"}}`, http.StatusUnsupportedMediaType, jsonapi.CodeUnsupportedMedia},
+		{"creating with another content type", http.MethodPost, jsonapi.ContentType, "application/json", `{"data":{"type":"articles"}}`, http.StatusUnsupportedMediaType, jsonapi.CodeUnsupportedMedia},
+		{"creating with a charset parameter", http.MethodPost, jsonapi.ContentType, "application/vnd.api+json; charset=utf-8", `{"data":{"type":"articles"}}`, http.StatusUnsupportedMediaType, jsonapi.CodeUnsupportedMedia},
+		{"creating with an unsupported extension", http.MethodPost, jsonapi.ContentType, `application/vnd.api+json; ext="https://example.com/ext"`, `{"data":{"type":"articles"}}`, http.StatusUnsupportedMediaType, jsonapi.CodeUnsupportedMedia},
+		{"creating with a profile", http.MethodPost, jsonapi.ContentType, `application/vnd.api+json; profile="https://example.com/profiles/flexible"`, `{"data":{"type":"articles"}}`, http.StatusCreated, ""},
+	} {
+		t.Run("When "+tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/articles/1", strings.NewReader(tt.body))
+			mustNotErr(t, err)
+			if tt.method == http.MethodPost {
+				req.URL.Path = "/articles"
+			}
+			req.Header.Set("Accept", tt.accept)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("it should respond with status %d", tt.status)
+				t.Log(res.Code)
+				t.Log(res.Body.String())
+			}
+			if tt.code == "" {
+				return
+			}
+			var doc struct {
+				Errors []jsonapi.Error `json:"errors"`
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil || len(doc.Errors) != 1 || doc.Errors[0].Code != tt.code {
+				t.Error("it should respond with an error document")
+				t.Log(res.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandle_ServeHTTP_ExtensionsAndProfiles(t *testing.T) {
+	const (
+		ext     = "https://example.com/ext/version"
+		profile = "https://example.com/profiles/timestamps"
+		unknown = "https://example.com/profiles/unknown"
+	)
+
+	var (
+		mux        jsonapi.ServeMux
+		negotiated jsonapi.MediaTypeParams
+	)
+	mux.RegisterExtension(ext)
+	mux.RegisterProfile(profile)
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		negotiated = jsonapi.Negotiated(req.Context())
+		res.SetData("articles", id, nil, nil, nil, nil)
+	}))
+
+	for _, tt := range []struct {
+		name, accept, contentType string
+
+		status      int
+		negotiated  jsonapi.MediaTypeParams
+		responseCT  string
+		responseDoc string
+	}{
+		{"no parameters are requested", jsonapi.ContentType, "", http.StatusOK, jsonapi.MediaTypeParams{}, jsonapi.ContentType, `{"data":{"id":"1","type":"articles"}}`},
+		{"a registered profile is accepted", `application/vnd.api+json; profile="` + profile + `"`, "", http.StatusOK, jsonapi.MediaTypeParams{Profile: []string{profile}}, `application/vnd.api+json; profile="` + profile + `"`, `{"data":{"id":"1","type":"articles"},"jsonapi":{"profile":["` + profile + `"]}}`},
+		{"an unknown profile is accepted", `application/vnd.api+json; profile="` + unknown + " " + profile + `"`, "", http.StatusOK, jsonapi.MediaTypeParams{Profile: []string{profile}}, `application/vnd.api+json; profile="` + profile + `"`, `{"data":{"id":"1","type":"articles"},"jsonapi":{"profile":["` + profile + `"]}}`},
+		{"a registered extension is accepted", `application/vnd.api+json; ext="` + ext + `"`, "", http.StatusOK, jsonapi.MediaTypeParams{Ext: []string{ext}}, `application/vnd.api+json; ext="` + ext + `"`, `{"data":{"id":"1","type":"articles"},"jsonapi":{"ext":["` + ext + `"]}}`},
+		{"the preferred media type has a registered extension", `application/vnd.api+json;q=0.5, application/vnd.api+json; ext="` + ext + `"`, "", http.StatusOK, jsonapi.MediaTypeParams{Ext: []string{ext}}, `application/vnd.api+json; ext="` + ext + `"`, `{"data":{"id":"1","type":"articles"},"jsonapi":{"ext":["` + ext + `"]}}`},
+		{"a wildcard is accepted with a registered extension in the request", "*/*", `application/vnd.api+json; ext="` + ext + `"`, http.StatusOK, jsonapi.MediaTypeParams{Ext: []string{ext}}, `application/vnd.api+json; ext="` + ext + `"`, `{"data":{"id":"1","type":"articles"},"jsonapi":{"ext":["` + ext + `"]}}`},
+		{"an unknown extension is accepted", `application/vnd.api+json; ext="` + unknown + `"`, "", http.StatusNotAcceptable, jsonapi.MediaTypeParams{}, jsonapi.ContentType, ""},
+		{"an unknown extension is in the request", jsonapi.ContentType, `application/vnd.api+json; ext="` + unknown + `"`, http.StatusUnsupportedMediaType, jsonapi.MediaTypeParams{}, jsonapi.ContentType, ""},
+	} {
+		t.Run("When "+tt.name, func(t *testing.T) {
+			negotiated = jsonapi.MediaTypeParams{}
+			req, err := http.NewRequest(http.MethodGet, "/articles/1", nil)
+			mustNotErr(t, err)
+			req.Header.Set("Accept", tt.accept)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("it should respond with status %d", tt.status)
+				t.Log(res.Code)
+			}
+			if got := res.Header().Get("Content-Type"); got != tt.responseCT {
+				t.Error("it should echo the applied parameters in the content type")
+				t.Log(got)
+			}
+			if !reflect.DeepEqual(negotiated, tt.negotiated) {
+				t.Error("it should make the applied parameters visible to the handler")
+				t.Log(negotiated)
+			}
+			if tt.responseDoc != "" && res.Body.String() != tt.responseDoc {
+				t.Error("it should list the applied parameters in the jsonapi object")
+				t.Log(res.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandle_ServeHTTP_HeadAndOptions(t *testing.T) {
+	var mux jsonapi.ServeMux
+	mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {
+		res.AppendData("articles", "1", nil, nil, nil, nil)
+	}))
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		res.SetData("articles", id, nil, nil, nil, nil)
+	}))
+	mux.HandleDelete("articles", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {}))
+	mux.HandleFetchRelated("articles", "author", jsonapi.FetchRelatedFunc(func(res jsonapi.FetchRelatedResponder, req *http.Request, id, relation string) {}))
+	mux.HandleUpdateRelationships("articles", "tags", jsonapi.UpdateRelationshipsFunc(func(res jsonapi.UpdateRelationshipsResponder, req *http.Request, id, relation string) {}))
+	mux.HandleAddToMany("articles", "tags", jsonapi.AddToManyFunc(func(res jsonapi.UpdateToManyResponder, req *http.Request, id, relation string, identities []jsonapi.Identity) {
+	}))
+	mux.HandleCreate("people", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {}))
+
+	for _, tt := range []struct {
+		path, allow string
+	}{
+		{"/articles", "GET, HEAD, OPTIONS"},
+		{"/articles/1", "GET, HEAD, DELETE, OPTIONS"},
+		{"/articles/1/author", "GET, HEAD, OPTIONS"},
+		{"/articles/1/relationships/tags", "POST, PATCH, OPTIONS"},
+		{"/people", "POST, OPTIONS"},
+	} {
+		t.Run("When requesting the options of "+tt.path, func(t *testing.T) {
+			req, err := jsonapi.NewRequest(http.MethodOptions, tt.path, nil)
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != http.StatusNoContent {
+				t.Error("it should respond with no content")
+				t.Log(res.Code)
+			}
+			if allow := res.Header().Get("Allow"); allow != tt.allow {
+				t.Error("it should allow the methods with registered handlers")
+				t.Log(allow)
+			}
+			if res.Body.Len() != 0 {
+				t.Error("it should not respond with a body")
+			}
+		})
+	}
+
+	for _, p := range []string{"/articles/1/comments", "/articles/1/relationships/author", "/articles/1/unknown", "/unknown"} {
+		t.Run("When requesting the options of "+p+" with no handlers", func(t *testing.T) {
+			req, err := jsonapi.NewRequest(http.MethodOptions, p, nil)
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != http.StatusNotFound {
+				t.Error("it should respond with not found")
+				t.Log(res.Code)
+			}
+			if allow := res.Header().Get("Allow"); allow != "" {
+				t.Error("it should not send allowed methods")
+				t.Log(allow)
+			}
+			var doc struct {
+				Errors []jsonapi.Error `json:"errors"`
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil || len(doc.Errors) != 1 || doc.Errors[0].Code != jsonapi.CodeNotFound {
+				t.Error("it should respond with an error document")
+				t.Log(res.Body.String())
+			}
+		})
+	}
+
+	t.Run("When requesting the head of a resource", func(t *testing.T) {
+		getReq, err := jsonapi.NewRequest(http.MethodGet, "/articles/1", nil)
+		mustNotErr(t, err)
+		getRes := httptest.NewRecorder()
+		mux.ServeHTTP(getRes, getReq)
+
+		req, err := jsonapi.NewRequest(http.MethodHead, "/articles/1", nil)
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != getRes.Code {
+			t.Error("it should respond with the status of a GET request")
+			t.Log(res.Code)
+		}
+		if res.Header().Get("Content-Type") != getRes.Header().Get("Content-Type") {
+			t.Error("it should respond with the headers of a GET request")
+		}
+		if res.Body.Len() != 0 {
+			t.Error("it should not respond with a body")
+			t.Log(res.Body.String())
+		}
+	})
+
+	t.Run("When the method is not supported", func(t *testing.T) {
+		req, err := jsonapi.NewRequest(http.MethodPut, "/articles/1", strings.NewReader(`{"data":null}`))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusMethodNotAllowed {
+			t.Error("it should respond with method not allowed")
+			t.Log(res.Code)
+		}
+		if allow := res.Header().Get("Allow"); allow != "GET, HEAD, DELETE, OPTIONS" {
+			t.Error("it should allow the methods with registered handlers")
+			t.Log(allow)
+		}
+		if body := res.Body.String(); body != `{"errors":[{"status":"405","code":"method-not-allowed","title":"Method Not Allowed","detail":"method PUT is not allowed"}]}` {
+			t.Error("it should respond with an error document")
+			t.Log(body)
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_Unimplemented(t *testing.T) {
+	var mux jsonapi.ServeMux
+	mux.HandleFetchCollection("articles", jsonapi.FetchCollectionFunc(func(res jsonapi.FetchCollectionResponder, req *http.Request) {}))
+	mux.HandleFetchRelationships("articles", "tags", nil)
+	mux.HandleDelete("articles", nil)
+	mux.HandleFetchOne("people", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {}))
+	mux.HandleCreate("comments", jsonapi.CreateFunc(func(res jsonapi.CreateResponder, req *http.Request) {
+		t.Error("it should not call the create handler")
+	}))
+	mux.HandleDelete("comments", jsonapi.DeleteFunc(func(res jsonapi.DeleteResponder, req *http.Request, id string) {
+		t.Error("it should not call the delete handler")
+	}))
+	mux.HandleUpdateRelationships("comments", "author", jsonapi.UpdateRelationshipsFunc(func(res jsonapi.UpdateRelationshipsResponder, req *http.Request, id, relation string) {
+		t.Error("it should not call the update relationships handler")
+	}))
+
+	for _, tt := range []struct {
+		method, path, body string
+
+		status int
+		code   string
+		allow  string
+	}{
+		{http.MethodPost, "/articles", `{"data":{"type":"articles"}}`, http.StatusForbidden, jsonapi.CodeUnsupportedAction, ""},
+		{http.MethodGet, "/articles/1", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodHead, "/articles/1", "", http.StatusNotFound, "", ""},
+		{http.MethodPatch, "/articles/1", `{"data":{"type":"articles","id":"1"}}`, http.StatusForbidden, jsonapi.CodeUnsupportedAction, ""},
+		{http.MethodDelete, "/articles/1", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodDelete, "/articles", "", http.StatusMethodNotAllowed, jsonapi.CodeMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{http.MethodGet, "/articles/1/author", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/articles/1/relationships/tags", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodPatch, "/articles/1/relationships/tags", `{"data":[]}`, http.StatusForbidden, jsonapi.CodeUnsupportedAction, ""},
+		{http.MethodPost, "/articles/1/relationships/tags", `{"data":[]}`, http.StatusForbidden, jsonapi.CodeUnsupportedAction, ""},
+		{http.MethodDelete, "/articles/1/relationships/tags", `{"data":[]}`, http.StatusForbidden, jsonapi.CodeUnsupportedAction, ""},
+		{http.MethodPatch, "/articles/1/author", `{"data":null}`, http.StatusForbidden, jsonapi.CodeUnsupportedAction, ""},
+		{http.MethodGet, "/nope", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/nope/1", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodPut, "/nope", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodPost, "/nope", `{"data":{"type":"nope"}}`, http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/people", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/people/1/relationships", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/people/1/relationships/", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodPut, "/people/1", "", http.StatusMethodNotAllowed, jsonapi.CodeMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{http.MethodDelete, "/comments", "", http.StatusMethodNotAllowed, jsonapi.CodeMethodNotAllowed, "POST, OPTIONS"},
+		{http.MethodPost, "/comments/1", `{"data":{"type":"comments"}}`, http.StatusMethodNotAllowed, jsonapi.CodeMethodNotAllowed, "DELETE, OPTIONS"},
+		{http.MethodPatch, "/comments/1/relationships/author/extra", `{"data":null}`, http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/articles/1/relationships/tags/extra", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodPost, "/articles/1/relationships/tags/extra", `{"data":[]}`, http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodGet, "/people/1/author/extra", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+		{http.MethodOptions, "/comments/1/relationships/author/extra", "", http.StatusNotFound, jsonapi.CodeNotFound, ""},
+	} {
+		t.Run("When "+tt.method+" "+tt.path+" is not implemented", func(t *testing.T) {
+			req, err := jsonapi.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			mustNotErr(t, err)
+			res := httptest.NewRecorder()
+
+			// Run
+			mux.ServeHTTP(res, req)
+
+			if res.Code != tt.status {
+				t.Errorf("it should respond with status %d", tt.status)
+				t.Log(res.Code)
+			}
+			if allow := res.Header().Get("Allow"); allow != tt.allow {
+				t.Error("it should only send the allowed methods with method not allowed")
+				t.Log(allow)
+			}
+			if tt.code == "" {
+				if res.Body.Len() != 0 {
+					t.Error("it should not respond with a body")
+				}
+				return
+			}
+			var doc struct {
+				Errors []jsonapi.Error `json:"errors"`
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil || len(doc.Errors) != 1 || doc.Errors[0].Code != tt.code {
+				t.Error("it should respond with an error document")
+				t.Log(res.Body.String())
+			}
+		})
+	}
+
+	t.Run("When the unimplemented policy is set", func(t *testing.T) {
+		mux := mux
+		mux.UnimplementedPolicy = func(method string, route jsonapi.Route, allowed []string) jsonapi.Error {
+			return jsonapi.ErrMethodNotAllowed(method)
+		}
+
+		req, err := jsonapi.NewRequest(http.MethodPost, "/articles", strings.NewReader(`{"data":{"type":"articles"}}`))
+		mustNotErr(t, err)
+		res := httptest.NewRecorder()
+
+		// Run
+		mux.ServeHTTP(res, req)
+
+		if res.Code != http.StatusMethodNotAllowed {
+			t.Error("it should respond with the error from the policy")
+			t.Log(res.Code)
+		}
+		if allow := res.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+			t.Error("it should send the allowed methods")
+			t.Log(allow)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SetFieldsets restricts the attributes and relationships of primary and
+// included resources to the requested fields when the document is encoded.
+// fields maps a resource type to its sparse fieldset as parsed from
+// `fields[type]`; resources of types without a fieldset are not changed.
+// ServeMux sets the fieldsets from the request so handlers need not trim
+// resources themselves.
+func (doc *TopLevelDocument) SetFieldsets(fields map[string][]string) {
+	doc.fieldsets = fields
+}
+
+// applyFieldsets replaces Data and Included with trimmed copies. It must only
+// be called on a copy of the document as in MarshalJSON.
+func (doc *TopLevelDocument) applyFieldsets() error {
+	switch data := doc.Data.(type) {
+	case *Resource:
+		if data != nil {
+			resource, err := doc.trimResource(*data)
+			if err != nil {
+				return err
+			}
+			doc.Data = &resource
+		}
+	case Resources:
+		resources, err := doc.trimResources(data)
+		if err != nil {
+			return err
+		}
+		doc.Data = resources
+	}
+
+	if doc.Included != nil {
+		included, err := doc.trimResources(doc.Included)
+		if err != nil {
+			return err
+		}
+		doc.Included = included
+	}
+	return nil
+}
+
+func (doc TopLevelDocument) trimResources(resources Resources) (Resources, error) {
+	trimmed := make(Resources, len(resources))
+	for i, resource := range resources {
+		var err error
+		if trimmed[i], err = doc.trimResource(resource); err != nil {
+			return nil, err
+		}
+	}
+	return trimmed, nil
+}
+
+func (doc TopLevelDocument) trimResource(resource Resource) (Resource, error) {
+	fields, ok := doc.fieldsets[resource.Type]
+	if !ok {
+		return resource, nil
+	}
+
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	if resource.Attributes != nil {
+		attributes, err := trimAttributes(resource.Attributes, allowed)
+		if err != nil {
+			return resource, fmt.Errorf("could not apply fieldset to %s %q: %s", resource.Type, resource.ID, err)
+		}
+		resource.Attributes = attributes
+	}
+
+	if resource.Relationships != nil {
+		relationships := make(Relationships)
+		for name, rel := range resource.Relationships {
+			if allowed[name] {
+				relationships[name] = rel
+			}
+		}
+		resource.Relationships = relationships
+	}
+
+	return resource, nil
+}
+
+// trimAttributes encodes attributes, which may be a struct, map or
+// json.RawMessage, and removes the members that are not allowed while
+// keeping the order of the remaining members. nil is returned when no
+// members remain so the attributes member is omitted.
+func trimAttributes(attributes interface{}, allowed map[string]bool) (interface{}, error) {
+	buf, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("attributes must be encoded as an object")
+	}
+
+	var (
+		trimmed bytes.Buffer
+		count   int
+	)
+	trimmed.WriteByte('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if !allowed[name] {
+			continue
+		}
+
+		if count > 0 {
+			trimmed.WriteByte(',')
+		}
+		nameBuf, _ := json.Marshal(name)
+		trimmed.Write(nameBuf)
+		trimmed.WriteByte(':')
+		trimmed.Write(value)
+		count++
+	}
+	trimmed.WriteByte('}')
+
+	if count == 0 {
+		return nil, nil
+	}
+	return json.RawMessage(trimmed.Bytes()), nil
+}
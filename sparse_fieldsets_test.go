@@ -0,0 +1,116 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/crhntr/jsonapi"
+)
+
+func TestTopLevelDocument_SetFieldsets(t *testing.T) {
+	type Article struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Views int    `json:"views"`
+	}
+
+	relationships := jsonapi.Relationships{}
+	relationships.SetToOne("author", "people", "9", nil)
+	relationships.AppendToMany("tags", "tags", "2", nil)
+
+	for _, tt := range []struct {
+		name       string
+		attributes interface{}
+	}{
+		{"struct", Article{"JSON:API", "paints my bikeshed", 3}},
+		{"map", map[string]interface{}{"views": 3, "body": "paints my bikeshed", "title": "JSON:API"}},
+		{"json.RawMessage", json.RawMessage(`{"title": "JSON:API", "body": "paints my bikeshed", "views": 3}`)},
+	} {
+		t.Run("when attributes are a "+tt.name, func(t *testing.T) {
+			var doc jsonapi.TopLevelDocument
+			doc.SetFieldsets(map[string][]string{"articles": {"views", "title", "author"}, "people": {}})
+			doc.SetData("articles", "1", tt.attributes, relationships, nil, nil)
+			doc.Include("people", "9", map[string]string{"name": "Dan"}, nil, nil, nil)
+			doc.Include("tags", "2", map[string]string{"label": "go"}, nil, nil, nil)
+
+			buf, err := json.Marshal(doc)
+			mustNotErr(t, err)
+
+			var decoded struct {
+				Data struct {
+					Attributes    map[string]interface{} `json:"attributes"`
+					Relationships map[string]interface{} `json:"relationships"`
+				} `json:"data"`
+				Included []map[string]interface{} `json:"included"`
+			}
+			mustNotErr(t, json.Unmarshal(buf, &decoded))
+
+			if len(decoded.Data.Attributes) != 2 || decoded.Data.Attributes["title"] != "JSON:API" || decoded.Data.Attributes["views"] != float64(3) {
+				t.Error("it should only encode the requested attributes")
+				t.Log(string(buf))
+			}
+			if _, ok := decoded.Data.Relationships["author"]; !ok || len(decoded.Data.Relationships) != 1 {
+				t.Error("it should only encode the requested relationships")
+				t.Log(string(buf))
+			}
+			if _, hasAttributes := decoded.Included[0]["attributes"]; hasAttributes {
+				t.Error("it should omit attributes for an empty fieldset")
+				t.Log(string(buf))
+			}
+			if _, hasAttributes := decoded.Included[1]["attributes"]; !hasAttributes {
+				t.Error("it should not change resources without a fieldset")
+				t.Log(string(buf))
+			}
+		})
+	}
+
+	t.Run("when the attributes order is preserved", func(t *testing.T) {
+		var doc jsonapi.TopLevelDocument
+		doc.SetFieldsets(map[string][]string{"articles": {"views", "title"}})
+		doc.AppendData("articles", "1", Article{"JSON:API", "paints my bikeshed", 3}, nil, nil, nil)
+
+		buf, err := json.Marshal(doc)
+		mustNotErr(t, err)
+		if string(buf) != `{"data":[{"id":"1","type":"articles","attributes":{"title":"JSON:API","views":3}}]}` {
+			t.Error("it should keep the attributes in encoding order")
+			t.Log(string(buf))
+		}
+	})
+
+	t.Run("when the document is encoded more than once", func(t *testing.T) {
+		var doc jsonapi.TopLevelDocument
+		doc.AppendData("articles", "1", Article{Title: "JSON:API"}, nil, nil, nil)
+		doc.SetFieldsets(map[string][]string{"articles": {"title"}})
+		_, err := json.Marshal(doc)
+		mustNotErr(t, err)
+
+		doc.SetFieldsets(nil)
+		buf, err := json.Marshal(doc)
+		mustNotErr(t, err)
+		if string(buf) != `{"data":[{"id":"1","type":"articles","attributes":{"title":"JSON:API","body":"","views":0}}]}` {
+			t.Error("it should not modify the resources")
+			t.Log(string(buf))
+		}
+	})
+}
+
+func TestHandle_ServeHTTP_SparseFieldsets(t *testing.T) {
+	req, err := jsonapi.NewRequest(http.MethodGet, "/articles/1?fields[articles]=title", nil)
+	mustNotErr(t, err)
+	res := httptest.NewRecorder()
+
+	var mux jsonapi.ServeMux
+	mux.HandleFetchOne("articles", jsonapi.FetchOneFunc(func(res jsonapi.FetchOneResonder, req *http.Request, id string) {
+		res.SetData("articles", id, map[string]string{"title": "JSON:API", "body": "paints my bikeshed"}, nil, nil, nil)
+	}))
+
+	// Run
+	mux.ServeHTTP(res, req)
+
+	if body := res.Body.String(); body != `{"data":{"id":"1","type":"articles","attributes":{"title":"JSON:API"}}}` {
+		t.Error("it should apply the requested fieldset")
+		t.Log(body)
+	}
+}